@@ -0,0 +1,140 @@
+package ecs
+
+import (
+	"reflect"
+	"slices"
+)
+
+// DeepCopier 组件类型实现该接口时，克隆组件会调用DeepCopy获取组件的拷贝，
+// 可以用它来自定义拷贝语义，比如拷贝未导出字段中的slice/map，或者深拷贝指针指向的对象。
+// 值接收者和指针接收者都可以。
+type DeepCopier[T any] interface {
+	DeepCopy() T
+}
+
+// newComponentCloner 生成组件类型T的拷贝函数，src为*T，返回值为T。
+// 组件的拷贝语义如下：
+//   - 实现了DeepCopier[T]的组件，使用DeepCopy的返回值；
+//   - 否则，组件的导出字段中的slice和map会被深拷贝（递归地处理数组、结构体、slice元素和map值），
+//     指针、interface、chan、func仍然与原组件共享，未导出的字段只做浅拷贝；
+//   - 不包含slice和map的组件，直接赋值拷贝。
+func newComponentCloner[T any]() func(src any) any {
+	if _, ok := any((*T)(nil)).(DeepCopier[T]); ok {
+		return func(src any) any {
+			return src.(DeepCopier[T]).DeepCopy()
+		}
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if !needDeepCopy(t) {
+		return func(src any) any {
+			return *src.(*T)
+		}
+	}
+	return func(src any) any {
+		return deepCopyValue(reflect.ValueOf(src).Elem()).Interface()
+	}
+}
+
+// 判断类型t是否含有需要深拷贝的slice或map
+func needDeepCopy(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Map:
+		return true
+	case reflect.Array:
+		return needDeepCopy(t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if field.IsExported() && needDeepCopy(field.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 按照newComponentCloner中描述的拷贝语义拷贝v
+func deepCopyValue(v reflect.Value) reflect.Value {
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(t).Elem()
+		for i := range v.Len() {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(t).Elem()
+		// 先整体赋值，未导出的字段只能浅拷贝
+		c.Set(v)
+		for i := range t.NumField() {
+			field := c.Field(i)
+			if field.CanSet() && needDeepCopy(field.Type()) {
+				field.Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+// Clone 在当前World中克隆entity，返回新的entity。
+// entity的所有组件都会按组件的拷贝语义（参考DeepCopier）拷贝到新entity上，相关过滤器只更新一次。
+// 父子关系：新entity会挂到原entity的父entity下，但不会克隆子entity；entity之间的关系（Relation）不会被克隆。
+func (w *World) Clone(entity Entity) Entity {
+	return w.CloneInto(w, entity)
+}
+
+// CloneInto 将当前World中的entity克隆到dst中，返回dst中新的entity，
+// 可以用于在不同的World之间迁移entity（克隆后再销毁原entity）。
+// 拷贝语义与Clone一致，克隆到其他World时不会保留父子关系。
+func (w *World) CloneInto(dst *World, entity Entity) Entity {
+	if entity.World() != w {
+		panic("entity does not belong to this world")
+	}
+	_, entityData := checkEntityAlive(entity)
+
+	components := make([]componentValue, 0, len(entityData.CompIndices))
+	for typeIndex, compPoolIdx := range entityData.CompIndices {
+		componentType := getComponentTypeByIndex(typeIndex)
+		// 父子关系由SetParent维护，不能直接拷贝组件数据
+		if componentType == parentComponentType || componentType == childrenComponentType {
+			continue
+		}
+		src := w.getComponentPoolByTypeIndex(typeIndex).GetRef(compPoolIdx)
+		components = append(components, componentValue{componentType: componentType, value: componentType.clone(src)})
+	}
+	// map的遍历顺序是随机的，按组件类型索引排序，保证组件事件的触发顺序一致
+	slices.SortFunc(components, func(a, b componentValue) int {
+		return a.componentType.TypeIndex - b.componentType.TypeIndex
+	})
+
+	clone := dst.newEntity()
+	applyComponents(dst, clone, dst.getEntityData(clone.Id), components, nil)
+	if dst == w {
+		if parent, ok := GetParent(entity); ok {
+			SetParent(clone, parent)
+		}
+	}
+	dst.Events.EntityCreated.Invoke(clone)
+	return clone
+}
@@ -0,0 +1,249 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type cloneTestItem struct {
+	Name string
+	Tags []string
+}
+
+type cloneTestBag struct {
+	Items    []cloneTestItem
+	ByName   map[string]cloneTestItem
+	Grid     [2][]int
+	Nil      []int
+	Empty    []int
+	NilMap   map[string]int
+	Shared   *cloneTestItem
+	private  []int
+	Capacity int
+}
+
+// 只有未导出的slice，直接赋值拷贝
+type cloneTestPrivate struct {
+	values []int
+}
+
+// 值接收者实现DeepCopier
+type cloneTestValueCopier struct {
+	Values []int
+}
+
+func (c cloneTestValueCopier) DeepCopy() cloneTestValueCopier {
+	return cloneTestValueCopier{Values: append([]int{-1}, c.Values...)}
+}
+
+// 指针接收者实现DeepCopier
+type cloneTestPointerCopier struct {
+	values []int
+}
+
+func (c *cloneTestPointerCopier) DeepCopy() cloneTestPointerCopier {
+	return cloneTestPointerCopier{values: append([]int{-2}, c.values...)}
+}
+
+type cloneTestKey struct {
+	V int
+}
+
+func init() {
+	RegisterComponentType[cloneTestBag](16)
+	RegisterComponentType[cloneTestPrivate](16)
+	RegisterComponentType[cloneTestValueCopier](16)
+	RegisterComponentType[cloneTestPointerCopier](16)
+	RegisterComponentType[cloneTestKey](16)
+}
+
+func newCloneTestBag() cloneTestBag {
+	return cloneTestBag{
+		Items:    []cloneTestItem{{Name: "a", Tags: []string{"x", "y"}}},
+		ByName:   map[string]cloneTestItem{"b": {Name: "b", Tags: []string{"z"}}},
+		Grid:     [2][]int{{1}, {2, 3}},
+		Empty:    []int{},
+		Shared:   &cloneTestItem{Name: "shared"},
+		private:  []int{1, 2},
+		Capacity: 8,
+	}
+}
+
+func TestCloneComponentSemantics(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(entity Entity)
+		// 修改原entity的组件数据
+		mutate func(entity Entity)
+		// 检查克隆出的entity的组件数据
+		check func(t *testing.T, src, clone Entity)
+	}{
+		{
+			name:  "nested slices and maps are deep copied",
+			setup: func(entity Entity) { Replace(entity, newCloneTestBag()) },
+			mutate: func(entity Entity) {
+				bag := Get[cloneTestBag](entity)
+				bag.Items[0].Name = "changed"
+				bag.Items[0].Tags[0] = "changed"
+				bag.ByName["b"].Tags[0] = "changed"
+				bag.ByName["new"] = cloneTestItem{}
+				bag.Grid[1][0] = 100
+			},
+			check: func(t *testing.T, src, clone Entity) {
+				got := Get[cloneTestBag](clone)
+				want := newCloneTestBag()
+				if !reflect.DeepEqual(got.Items, want.Items) {
+					t.Errorf("Items = %+v, want %+v", got.Items, want.Items)
+				}
+				if !reflect.DeepEqual(got.ByName, want.ByName) {
+					t.Errorf("ByName = %+v, want %+v", got.ByName, want.ByName)
+				}
+				if !reflect.DeepEqual(got.Grid, want.Grid) {
+					t.Errorf("Grid = %v, want %v", got.Grid, want.Grid)
+				}
+				if got.Capacity != want.Capacity {
+					t.Errorf("Capacity = %d, want %d", got.Capacity, want.Capacity)
+				}
+			},
+		},
+		{
+			name:  "nil and empty values are preserved",
+			setup: func(entity Entity) { Replace(entity, newCloneTestBag()) },
+			check: func(t *testing.T, src, clone Entity) {
+				got := Get[cloneTestBag](clone)
+				if got.Nil != nil {
+					t.Errorf("Nil = %#v, want nil", got.Nil)
+				}
+				if got.Empty == nil || len(got.Empty) != 0 {
+					t.Errorf("Empty = %#v, want empty non-nil", got.Empty)
+				}
+				if got.NilMap != nil {
+					t.Errorf("NilMap = %#v, want nil", got.NilMap)
+				}
+			},
+		},
+		{
+			name:  "pointers are shared",
+			setup: func(entity Entity) { Replace(entity, newCloneTestBag()) },
+			check: func(t *testing.T, src, clone Entity) {
+				if Get[cloneTestBag](clone).Shared != Get[cloneTestBag](src).Shared {
+					t.Errorf("pointer field is not shared")
+				}
+			},
+		},
+		{
+			name:   "unexported fields stay shallow",
+			setup:  func(entity Entity) { Replace(entity, newCloneTestBag()) },
+			mutate: func(entity Entity) { Get[cloneTestBag](entity).private[0] = 100 },
+			check: func(t *testing.T, src, clone Entity) {
+				if got := Get[cloneTestBag](clone).private[0]; got != 100 {
+					t.Errorf("private[0] = %d, want 100 (shared with the source)", got)
+				}
+			},
+		},
+		{
+			name:   "only unexported fields",
+			setup:  func(entity Entity) { Replace(entity, cloneTestPrivate{values: []int{1}}) },
+			mutate: func(entity Entity) { Get[cloneTestPrivate](entity).values[0] = 100 },
+			check: func(t *testing.T, src, clone Entity) {
+				if got := Get[cloneTestPrivate](clone).values[0]; got != 100 {
+					t.Errorf("values[0] = %d, want 100 (shared with the source)", got)
+				}
+			},
+		},
+		{
+			name:  "DeepCopier with value receiver",
+			setup: func(entity Entity) { Replace(entity, cloneTestValueCopier{Values: []int{1}}) },
+			check: func(t *testing.T, src, clone Entity) {
+				if got, want := Get[cloneTestValueCopier](clone).Values, []int{-1, 1}; !reflect.DeepEqual(got, want) {
+					t.Errorf("Values = %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:  "DeepCopier with pointer receiver",
+			setup: func(entity Entity) { Replace(entity, cloneTestPointerCopier{values: []int{1}}) },
+			check: func(t *testing.T, src, clone Entity) {
+				if got, want := Get[cloneTestPointerCopier](clone).values, []int{-2, 1}; !reflect.DeepEqual(got, want) {
+					t.Errorf("values = %v, want %v", got, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			src := world.NewEntity()
+			tt.setup(src)
+			clone := world.Clone(src)
+			if clone == src || !clone.IsAlive() {
+				t.Fatalf("Clone returned %+v for %+v", clone, src)
+			}
+			if tt.mutate != nil {
+				tt.mutate(src)
+			}
+			tt.check(t, src, clone)
+		})
+	}
+}
+
+func TestCloneIntoOtherWorld(t *testing.T) {
+	newWorld := func() (*World, *Filter1[cloneTestKey], *GroupFilter[cloneTestKey]) {
+		world := NewWorld()
+		filter := RegisterFilter(world, NewFilter1[cloneTestKey](world))
+		gf := RegisterGroupFilter(world, NewGroupFilter[cloneTestKey](world))
+		return world, filter, gf
+	}
+	src, srcFilter, srcGroup := newWorld()
+	dst, dstFilter, dstGroup := newWorld()
+	entity := src.NewEntity()
+	AddComponents(entity, cloneTestKey{V: 7}, cloneTestValueCopier{Values: []int{1}})
+
+	created := 0
+	dst.OnEntityCreated(func(Entity) { created++ })
+	clone := src.CloneInto(dst, entity)
+	if clone.World() != dst {
+		t.Fatalf("clone belongs to another world")
+	}
+	if created != 1 {
+		t.Errorf("EntityCreated fired %d times, want 1", created)
+	}
+	if got := dstFilter.Len(); got != 1 {
+		t.Errorf("dst filter Len = %d, want 1", got)
+	}
+	if found, ok := dstGroup.FindOne(cloneTestKey{V: 7}); !ok || found != clone {
+		t.Errorf("dst FindOne = %v, %v, want %v, true", found, ok, clone)
+	}
+	if got, want := Get[cloneTestValueCopier](clone).Values, []int{-1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values = %v, want %v", got, want)
+	}
+
+	// 迁移：克隆后销毁原entity，不影响dst
+	entity.Destroy()
+	if got := srcFilter.Len(); got != 0 {
+		t.Errorf("src filter Len = %d, want 0", got)
+	}
+	if _, ok := srcGroup.FindOne(cloneTestKey{V: 7}); ok {
+		t.Errorf("src still indexes the destroyed entity")
+	}
+	if found, ok := dstGroup.FindOne(cloneTestKey{V: 7}); !ok || found != clone {
+		t.Errorf("dst FindOne after destroying src = %v, %v, want %v, true", found, ok, clone)
+	}
+
+	// 在dst中修改key，dst的索引更新
+	Replace(clone, cloneTestKey{V: 8})
+	if found, ok := dstGroup.FindOne(cloneTestKey{V: 8}); !ok || found != clone {
+		t.Errorf("dst FindOne(new key) = %v, %v, want %v, true", found, ok, clone)
+	}
+}
+
+func TestCloneIntoPanicsOnForeignEntity(t *testing.T) {
+	src, dst := NewWorld(), NewWorld()
+	entity := src.NewEntity()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("CloneInto did not panic for an entity of another world")
+		}
+	}()
+	dst.CloneInto(src, entity)
+}
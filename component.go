@@ -13,6 +13,9 @@ var TypeIndex int
 // 目前，使用组件之前必须先使用RegisterComponentType注册组件类型
 var componentTypeMap = make(map[reflect.Type]*ComponentType)
 
+// 所有已注册的组件，//<组件类型索引, 组件类型数据>
+var componentTypeIndexMap = make(map[int]*ComponentType)
+
 // 组件类型数据
 type ComponentType struct {
 	// 组件类型索引，也可以理解为组件类型的ID，组件类型的索引是唯一的
@@ -27,6 +30,41 @@ type ComponentType struct {
 	PoolSegmentSize int
 	// 与该组件变更的相关事件，外部可以通过它来监听具体组件的变更
 	Events EntityEvents
+
+	// 以下是类型擦除后的组件操作，用于在不知道具体组件类型时（比如Prefab）操作组件
+	// 创建该组件类型的对象池
+	newPool func() ComponentPooler
+	// 将any类型的组件值src写入组件指针dst（*T）
+	assign func(dst any, src any)
+	// 使用指定的反序列化函数，将data解析为any类型的组件值
+	decode func(data []byte, unmarshal func([]byte, any) error) (any, error)
+	// 按组件的拷贝语义拷贝组件，src为组件指针（*T），返回any类型的组件值，参考newComponentCloner
+	clone func(src any) any
+	// 与clone相同，只是src为any类型的组件值（T）
+	cloneValue func(src any) any
+	// 组件类型是否实现了ComponentOnAdder接口
+	hasOnAdd bool
+	// 组件类型是否实现了ComponentOnRemover接口
+	hasOnRemove bool
+}
+
+// ComponentOnAdder 组件类型实现该接口时，组件添加到entity后（相关过滤器都已更新）会自动调用OnAdd。
+// 值接收者和指针接收者都可以，使用指针接收者时可以在OnAdd中修改组件数据。
+type ComponentOnAdder interface {
+	OnAdd(entity Entity)
+}
+
+// ComponentOnRemover 组件类型实现该接口时，组件从entity删除前（包括entity销毁时）会自动调用OnRemove，
+// 此时entity已经从相关过滤器中移除，但组件数据还未回收。
+type ComponentOnRemover interface {
+	OnRemove(entity Entity)
+}
+
+// ComponentResetter 组件类型实现该接口时，每次从对象池分配组件时都会先调用Reset，
+// 用于将复用的对象池槽位恢复到组件的初始状态，比如设置非零的默认值。
+// 注意，Reset需要使用指针接收者。
+type ComponentResetter interface {
+	Reset()
 }
 
 // 注册组件类型
@@ -38,8 +76,27 @@ func RegisterComponentType[T any](poolSegmentSize int) *ComponentType {
 		Type:            t,
 		Flag:            1 << (TypeIndex % 64),
 		PoolSegmentSize: poolSegmentSize,
+		newPool: func() ComponentPooler {
+			return newComponentPool[T]()
+		},
+		assign: func(dst any, src any) {
+			*dst.(*T) = src.(T)
+		},
+		decode: func(data []byte, unmarshal func([]byte, any) error) (any, error) {
+			var v T
+			err := unmarshal(data, &v)
+			return v, err
+		},
+	}
+	_, ct.hasOnAdd = any((*T)(nil)).(ComponentOnAdder)
+	_, ct.hasOnRemove = any((*T)(nil)).(ComponentOnRemover)
+	ct.clone = newComponentCloner[T]()
+	ct.cloneValue = func(src any) any {
+		v := src.(T)
+		return ct.clone(&v)
 	}
 	componentTypeMap[t] = ct
+	componentTypeIndexMap[ct.TypeIndex] = ct
 	return ct
 }
 
@@ -53,6 +110,45 @@ func GetComponentType[T any]() *ComponentType {
 	return componentType
 }
 
+// 根据组件类型索引获取组件类型数据
+func getComponentTypeByIndex(typeIndex int) *ComponentType {
+	return componentTypeIndexMap[typeIndex]
+}
+
+// 根据组件类型名称（不含包名）获取组件类型数据，用于从数据中加载组件
+func getComponentTypeByName(name string) (*ComponentType, error) {
+	var found *ComponentType
+	for t, ct := range componentTypeMap {
+		if t.Name() != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("component name:%s is ambiguous", name)
+		}
+		found = ct
+	}
+	if found == nil {
+		return nil, fmt.Errorf("component name:%s not register", name)
+	}
+	return found, nil
+}
+
+// componentValue 类型擦除后的组件值，用于批量地添加组件
+type componentValue struct {
+	componentType *ComponentType
+	value         any
+}
+
+// 将任意已注册的组件值包装为componentValue，component必须是组件值而不是指针
+func newComponentValue(component any) componentValue {
+	t := reflect.TypeOf(component)
+	componentType, ok := componentTypeMap[t]
+	if !ok {
+		panic(fmt.Sprintf("component type %v is not registered", t))
+	}
+	return componentValue{componentType: componentType, value: component}
+}
+
 type ComponentPooler interface {
 	Alloc() (int, any)
 	GetRef(id int) any
@@ -63,10 +159,25 @@ type ComponentPooler interface {
 // 类型T需要是一个结构体才能发挥作用。如果是一个指针，没啥意义。
 type ComponentPool[T any] struct {
 	pool *dataPool.Pool[T]
+	// T是否实现了ComponentResetter接口
+	reset bool
+}
+
+func newComponentPool[T any]() *ComponentPool[T] {
+	_, reset := any((*T)(nil)).(ComponentResetter)
+	return &ComponentPool[T]{
+		pool:  dataPool.NewPool[T](segmentSize),
+		reset: reset,
+	}
 }
 
+// 分配一个组件，如果组件实现了ComponentResetter接口，会先调用Reset
 func (cp *ComponentPool[T]) Alloc() (int, any) {
-	return cp.pool.Alloc()
+	idx, comp := cp.pool.Alloc()
+	if cp.reset {
+		any(comp).(ComponentResetter).Reset()
+	}
+	return idx, comp
 }
 
 func (cp *ComponentPool[T]) GetRef(id int) any {
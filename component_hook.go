@@ -0,0 +1,129 @@
+package ecs
+
+// 类型擦除后的组件钩子，方便World在不知道组件具体类型时触发钩子
+type iComponentHooks interface {
+	// comp为组件指针（*T）
+	fireAdd(entity Entity, comp any)
+	// comp为组件指针（*T）
+	fireRemove(entity Entity, comp any)
+	// oldComp、newComp为组件指针（*T），oldComp是替换前的组件数据的拷贝
+	fireReplace(entity Entity, oldComp, newComp any)
+	// 是否有OnReplace钩子，没有则不需要拷贝替换前的组件数据
+	hasReplace() bool
+	// 拷贝一份组件数据，comp为组件指针（*T），返回值也是组件指针
+	snapshot(comp any) any
+}
+
+// componentHooks 某一种组件类型T在World中的类型化钩子，
+// 与ComponentType.Events不同，它是World级别的，并且回调中直接携带组件数据。
+type componentHooks[T any] struct {
+	onAdd     callbackList[func(Entity, *T)]
+	onRemove  callbackList[func(Entity, *T)]
+	onReplace callbackList[func(Entity, *T, *T)]
+}
+
+func (h *componentHooks[T]) fireAdd(entity Entity, comp any) {
+	h.onAdd.foreach(func(cb func(Entity, *T)) {
+		cb(entity, comp.(*T))
+	})
+}
+
+func (h *componentHooks[T]) fireRemove(entity Entity, comp any) {
+	h.onRemove.foreach(func(cb func(Entity, *T)) {
+		cb(entity, comp.(*T))
+	})
+}
+
+func (h *componentHooks[T]) fireReplace(entity Entity, oldComp, newComp any) {
+	h.onReplace.foreach(func(cb func(Entity, *T, *T)) {
+		cb(entity, oldComp.(*T), newComp.(*T))
+	})
+}
+
+func (h *componentHooks[T]) hasReplace() bool {
+	return len(h.onReplace.entries) > 0
+}
+
+func (h *componentHooks[T]) snapshot(comp any) any {
+	old := *comp.(*T)
+	return &old
+}
+
+// 获取组件类型T在world中的钩子，不存在则创建
+func getComponentHooks[T any](w *World) *componentHooks[T] {
+	componentType := GetComponentType[T]()
+	hooks, ok := w.componentHooks[componentType.TypeIndex]
+	if !ok {
+		hooks = &componentHooks[T]{}
+		w.componentHooks[componentType.TypeIndex] = hooks
+	}
+	return hooks.(*componentHooks[T])
+}
+
+// OnAdd 监听world中entity添加T组件，回调时组件已经添加完成，并且相关过滤器都已更新。
+// 返回的Subscription用于取消监听。
+// 注意，不要持有回调中的组件指针，参考TryGet的注释。
+func OnAdd[T any](w *World, fn func(entity Entity, comp *T)) Subscription {
+	return getComponentHooks[T](w).onAdd.add(fn)
+}
+
+// OnRemove 监听world中entity删除T组件（包括entity销毁时删除组件），
+// 回调时entity已经从相关过滤器中移除，但组件数据还未回收，old即为被删除的组件数据。
+// 返回的Subscription用于取消监听。
+func OnRemove[T any](w *World, fn func(entity Entity, old *T)) Subscription {
+	return getComponentHooks[T](w).onRemove.add(fn)
+}
+
+// OnReplace 监听world中entity替换已有的T组件（Replace、AddComponents），
+// oldComp是替换前的组件数据的浅拷贝，newComp是替换后的组件数据。
+// 返回的Subscription用于取消监听。
+func OnReplace[T any](w *World, fn func(entity Entity, oldComp, newComp *T)) Subscription {
+	return getComponentHooks[T](w).onReplace.add(fn)
+}
+
+// 触发组件添加的钩子：先调用组件自身的OnAdd方法（ComponentOnAdder），再调用OnAdd[T]注册的钩子
+func (w *World) fireComponentAdded(componentType *ComponentType, entity Entity, compPoolIdx int) {
+	hooks, ok := w.componentHooks[componentType.TypeIndex]
+	if !ok && !componentType.hasOnAdd {
+		return
+	}
+	comp := w.getComponentPoolByTypeIndex(componentType.TypeIndex).GetRef(compPoolIdx)
+	if componentType.hasOnAdd {
+		comp.(ComponentOnAdder).OnAdd(entity)
+	}
+	if ok {
+		hooks.fireAdd(entity, comp)
+	}
+}
+
+// 触发组件删除的钩子：先调用组件自身的OnRemove方法（ComponentOnRemover），再调用OnRemove[T]注册的钩子
+func (w *World) fireComponentRemoved(componentType *ComponentType, entity Entity, compPoolIdx int) {
+	hooks, ok := w.componentHooks[componentType.TypeIndex]
+	if !ok && !componentType.hasOnRemove {
+		return
+	}
+	comp := w.getComponentPoolByTypeIndex(componentType.TypeIndex).GetRef(compPoolIdx)
+	if componentType.hasOnRemove {
+		comp.(ComponentOnRemover).OnRemove(entity)
+	}
+	if ok {
+		hooks.fireRemove(entity, comp)
+	}
+}
+
+// 若存在OnReplace钩子，则拷贝一份替换前的组件数据，否则返回 nil
+func (w *World) snapshotForReplace(typeIndex int, comp any) any {
+	hooks, ok := w.componentHooks[typeIndex]
+	if !ok || !hooks.hasReplace() {
+		return nil
+	}
+	return hooks.snapshot(comp)
+}
+
+// 触发组件替换的钩子，oldComp为snapshotForReplace的返回值
+func (w *World) fireComponentReplaced(typeIndex int, entity Entity, oldComp, newComp any) {
+	if oldComp == nil {
+		return
+	}
+	w.componentHooks[typeIndex].fireReplace(entity, oldComp, newComp)
+}
@@ -0,0 +1,123 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type hookTestHp struct {
+	Val int
+}
+
+func init() {
+	RegisterComponentType[hookTestHp](16)
+}
+
+func TestComponentHooks(t *testing.T) {
+	tests := []struct {
+		name string
+		// 在一个没有hookTestHp组件的entity上操作
+		run  func(entity Entity)
+		want []string
+	}{
+		{
+			name: "Replace adds",
+			run:  func(entity Entity) { Replace(entity, hookTestHp{Val: 1}) },
+			want: []string{"add 1"},
+		},
+		{
+			name: "Replace replaces",
+			run: func(entity Entity) {
+				Replace(entity, hookTestHp{Val: 1})
+				Replace(entity, hookTestHp{Val: 2})
+			},
+			want: []string{"add 1", "replace 1->2"},
+		},
+		{
+			name: "AddComponents replaces",
+			run: func(entity Entity) {
+				AddComponents(entity, hookTestHp{Val: 1})
+				AddComponents(entity, hookTestHp{Val: 2})
+			},
+			want: []string{"add 1", "replace 1->2"},
+		},
+		{
+			name: "Del",
+			run: func(entity Entity) {
+				Replace(entity, hookTestHp{Val: 1})
+				Del[hookTestHp](entity)
+				// 没有该组件时不会触发
+				Del[hookTestHp](entity)
+			},
+			want: []string{"add 1", "remove 1"},
+		},
+		{
+			name: "Destroy",
+			run: func(entity Entity) {
+				Replace(entity, hookTestHp{Val: 1})
+				entity.Destroy()
+			},
+			want: []string{"add 1", "remove 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			filter := RegisterFilter(world, NewFilter1[hookTestHp](world))
+			var log []string
+			OnAdd(world, func(entity Entity, comp *hookTestHp) {
+				// 回调时过滤器已经更新
+				if !filter.Contains(entity) {
+					t.Errorf("entity is not in the filter in OnAdd")
+				}
+				log = append(log, fmt.Sprintf("add %d", comp.Val))
+			})
+			OnRemove(world, func(entity Entity, old *hookTestHp) {
+				if filter.Contains(entity) {
+					t.Errorf("entity is still in the filter in OnRemove")
+				}
+				log = append(log, fmt.Sprintf("remove %d", old.Val))
+			})
+			OnReplace(world, func(entity Entity, oldComp, newComp *hookTestHp) {
+				log = append(log, fmt.Sprintf("replace %d->%d", oldComp.Val, newComp.Val))
+			})
+			// 其他world的钩子不会被触发
+			other := NewWorld()
+			OnAdd(other, func(Entity, *hookTestHp) {
+				t.Errorf("hook of another world is called")
+			})
+
+			tt.run(world.NewEntity())
+			if !slices.Equal(log, tt.want) {
+				t.Errorf("got %v, want %v", log, tt.want)
+			}
+		})
+	}
+}
+
+func TestComponentHooksUnsubscribe(t *testing.T) {
+	world := NewWorld()
+	var log []string
+	add := OnAdd(world, func(Entity, *hookTestHp) { log = append(log, "add") })
+	remove := OnRemove(world, func(Entity, *hookTestHp) { log = append(log, "remove") })
+	replace := OnReplace(world, func(Entity, *hookTestHp, *hookTestHp) { log = append(log, "replace") })
+	entity := world.NewEntity()
+	Replace(entity, hookTestHp{Val: 1})
+	Replace(entity, hookTestHp{Val: 2})
+	Del[hookTestHp](entity)
+	if want := []string{"add", "replace", "remove"}; !slices.Equal(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+
+	add.Unsubscribe()
+	remove.Unsubscribe()
+	replace.Unsubscribe()
+	log = nil
+	Replace(entity, hookTestHp{Val: 1})
+	Replace(entity, hookTestHp{Val: 2})
+	Del[hookTestHp](entity)
+	if len(log) != 0 {
+		t.Errorf("got %v after Unsubscribe, want none", log)
+	}
+}
@@ -0,0 +1,150 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// 指针接收者实现ComponentOnAdder、ComponentOnRemover
+type lifecycleTestPtr struct {
+	Log *[]string
+	Val int
+}
+
+func (c *lifecycleTestPtr) OnAdd(entity Entity) {
+	*c.Log = append(*c.Log, fmt.Sprintf("ptr add %d", c.Val))
+	// 指针接收者可以修改组件数据
+	c.Val *= 10
+}
+
+func (c *lifecycleTestPtr) OnRemove(entity Entity) {
+	*c.Log = append(*c.Log, fmt.Sprintf("ptr remove %d", c.Val))
+}
+
+// 值接收者实现ComponentOnAdder、ComponentOnRemover
+type lifecycleTestValue struct {
+	Log *[]string
+}
+
+func (c lifecycleTestValue) OnAdd(entity Entity) {
+	*c.Log = append(*c.Log, "value add")
+}
+
+func (c lifecycleTestValue) OnRemove(entity Entity) {
+	*c.Log = append(*c.Log, "value remove")
+}
+
+// 实现ComponentResetter，Speed的默认值不是零值
+type lifecycleTestReset struct {
+	Speed int
+	Items []int
+}
+
+func (c *lifecycleTestReset) Reset() {
+	*c = lifecycleTestReset{Speed: 10}
+}
+
+func init() {
+	RegisterComponentType[lifecycleTestPtr](16)
+	RegisterComponentType[lifecycleTestValue](16)
+	RegisterComponentType[lifecycleTestReset](16)
+}
+
+func TestComponentLifecycleMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(entity Entity, log *[]string)
+		want []string
+	}{
+		{
+			name: "Replace and Del",
+			run: func(entity Entity, log *[]string) {
+				Replace(entity, lifecycleTestPtr{Log: log, Val: 1})
+				Del[lifecycleTestPtr](entity)
+			},
+			want: []string{"ptr add 1", "hook add 10", "ptr remove 10", "hook remove 10"},
+		},
+		{
+			name: "Replace existing",
+			run: func(entity Entity, log *[]string) {
+				Replace(entity, lifecycleTestPtr{Log: log, Val: 1})
+				// 替换已有的组件不会调用OnAdd
+				Replace(entity, lifecycleTestPtr{Log: log, Val: 2})
+			},
+			want: []string{"ptr add 1", "hook add 10"},
+		},
+		{
+			name: "value receiver",
+			run: func(entity Entity, log *[]string) {
+				AddComponents(entity, lifecycleTestValue{Log: log})
+				Del[lifecycleTestValue](entity)
+			},
+			want: []string{"value add", "value remove"},
+		},
+		{
+			name: "Destroy",
+			run: func(entity Entity, log *[]string) {
+				AddComponents(entity, lifecycleTestPtr{Log: log, Val: 1})
+				entity.Destroy()
+			},
+			want: []string{"ptr add 1", "hook add 10", "ptr remove 10", "hook remove 10"},
+		},
+		{
+			name: "DestroyDeferred",
+			run: func(entity Entity, log *[]string) {
+				Replace(entity, lifecycleTestValue{Log: log})
+				entity.DestroyDeferred()
+				*log = append(*log, "flush")
+				entity.World().FlushDestroyed()
+			},
+			want: []string{"value add", "flush", "value remove"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			filter := RegisterFilter(world, NewFilter1[lifecycleTestPtr](world))
+			var log []string
+			// 组件自身的方法先于OnAdd、OnRemove钩子调用
+			OnAdd(world, func(entity Entity, comp *lifecycleTestPtr) {
+				log = append(log, fmt.Sprintf("hook add %d", comp.Val))
+			})
+			OnRemove(world, func(entity Entity, old *lifecycleTestPtr) {
+				if filter.Contains(entity) {
+					t.Errorf("entity is still in the filter in OnRemove")
+				}
+				log = append(log, fmt.Sprintf("hook remove %d", old.Val))
+			})
+
+			tt.run(world.NewEntity(), &log)
+			if !slices.Equal(log, tt.want) {
+				t.Errorf("got %v, want %v", log, tt.want)
+			}
+		})
+	}
+}
+
+func TestComponentResetOnAlloc(t *testing.T) {
+	world := NewWorld()
+	a := world.NewEntity()
+	comp := EnsureMayForWrite[lifecycleTestReset](a)
+	if comp.Speed != 10 || comp.Items != nil {
+		t.Fatalf("new component = %+v, want {Speed:10 Items:[]}", *comp)
+	}
+	comp.Speed = 3
+	comp.Items = append(comp.Items, 1)
+	a.Destroy()
+
+	// 复用对象池中被释放的槽位时，同样恢复到初始状态
+	b := world.NewEntity()
+	comp = EnsureMayForWrite[lifecycleTestReset](b)
+	if comp.Speed != 10 || comp.Items != nil {
+		t.Errorf("reused component = %+v, want {Speed:10 Items:[]}", *comp)
+	}
+	Del[lifecycleTestReset](b)
+	comp = EnsureMayForWrite[lifecycleTestReset](b)
+	if comp.Speed != 10 || comp.Items != nil {
+		t.Errorf("component added again = %+v, want {Speed:10 Items:[]}", *comp)
+	}
+}
@@ -1,19 +1,15 @@
 package ecs
 
-import (
-	"unsafe"
-)
-
 // 一个实体，包含一个ID和一个版本号
 type Entity struct {
 	Id  int
 	Gen uint
 	// 指向实体所属世界的指针
-	WorldPtr uintptr
+	WorldPtr *World
 }
 
 func (e *Entity) World() *World {
-	return (*World)(unsafe.Pointer(e.WorldPtr))
+	return e.WorldPtr
 }
 
 // 比较两个实体是否相等
@@ -23,10 +19,31 @@ func (e *Entity) Equal(entity Entity) bool {
 }
 
 // 销毁实体
+// 若实体有子实体，子实体不会被销毁，而是成为根实体；需要连同子孙一起销毁请使用DestroyRecursive
 func (e *Entity) Destroy() {
 	destroyEntity(*e)
 }
 
+// DestroyDeferred 延迟销毁实体，等同于World.QueueDestroy
+func (e *Entity) DestroyDeferred() {
+	e.World().QueueDestroy(*e)
+}
+
+// DestroyRecursive 销毁实体及其所有子孙实体，子孙实体先于祖先实体被销毁
+func (e *Entity) DestroyRecursive() {
+	if !e.IsAlive() {
+		return
+	}
+	entities := []Entity{*e}
+	ForeachDescendant(*e, func(entity Entity) bool {
+		entities = append(entities, entity)
+		return true
+	})
+	for i := len(entities) - 1; i >= 0; i-- {
+		destroyEntity(entities[i])
+	}
+}
+
 // 检查实体是否存活
 // 如果实体的版本号与当前实体数据的版本号不相等，说明实体已经被销毁过，视为不存活
 func (e *Entity) IsAlive() bool {
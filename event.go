@@ -1,53 +1,187 @@
 package ecs
 
-import "reflect"
+import (
+	"math"
+	"reflect"
+	"slices"
+)
+
+// 回调的优先级，数值越小越先被调用，相同优先级的回调按注册顺序调用。
+const (
+	// PriorityInternal 框架内部的索引维护（比如GroupFilter）使用的优先级，总是先于其他回调被调用
+	PriorityInternal = math.MinInt
+	// PriorityDefault AddCallback、AddListener、OnAdd等不指定优先级的注册函数使用的优先级
+	PriorityDefault = 0
+)
+
+// Subscription 订阅凭证，由AddCallback、OnAdd、AddListener等注册函数返回，用于取消订阅。
+// 零值的Subscription也可以安全地调用Unsubscribe。
+type Subscription struct {
+	unsubscribe func()
+}
+
+// Unsubscribe 取消订阅，重复调用是安全的。
+// 在回调执行期间（包括在回调自身中）取消订阅也是安全的，被取消的回调不会再被调用。
+func (s Subscription) Unsubscribe() {
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+}
+
+type callbackEntry[F any] struct {
+	callback F
+	priority int
+	removed  bool
+}
+
+// callbackList 回调列表，是Delegate、过滤器监听等的底层实现。
+// 回调按优先级从小到大排列，相同优先级的按注册顺序排列。
+// 每个回调对应一个Subscription，保证在遍历回调期间增删回调是安全的：
+// 遍历期间新增的回调不会在本次遍历中被调用（无论优先级），取消订阅的回调会被跳过。
+//
+// 关于重入：回调是同步调用的，回调中修改world（增删组件、创建/销毁entity等）会立即触发相应的事件，
+// 即嵌套地遍历回调，外层遍历中后续的回调看到的是修改后的状态。
+// 比如，过滤器的OnAdd回调中删除了entity的组件，后续的OnAdd回调被调用时，entity可能已经不在过滤器中了，
+// 所以回调中需要时应先检查IsAlive/Has；如果只是想销毁entity，可以使用DestroyDeferred避免嵌套。
+type callbackList[F any] struct {
+	entries []*callbackEntry[F]
+	// 正在遍历的层数（回调中可能再次触发遍历），遍历期间取消订阅只做标记，遍历结束后再整理
+	iterating int
+	// 是否有被标记删除、尚未整理的回调
+	dirty bool
+}
+
+func (l *callbackList[F]) add(callback F) Subscription {
+	return l.addWithPriority(callback, PriorityDefault)
+}
+
+// 按优先级注册回调，插入到所有优先级不大于priority的回调之后
+func (l *callbackList[F]) addWithPriority(callback F, priority int) Subscription {
+	entry := &callbackEntry[F]{callback: callback, priority: priority}
+	pos := len(l.entries)
+	for pos > 0 && l.entries[pos-1].priority > priority {
+		pos--
+	}
+	if l.iterating > 0 {
+		// 正在遍历的是旧的slice，不能原地插入，否则会打乱遍历的顺序
+		entries := make([]*callbackEntry[F], 0, len(l.entries)+1)
+		entries = append(entries, l.entries[:pos]...)
+		entries = append(entries, entry)
+		l.entries = append(entries, l.entries[pos:]...)
+	} else {
+		l.entries = slices.Insert(l.entries, pos, entry)
+	}
+	return Subscription{unsubscribe: func() {
+		l.remove(entry)
+	}}
+}
+
+func (l *callbackList[F]) remove(entry *callbackEntry[F]) {
+	if entry.removed {
+		return
+	}
+	entry.removed = true
+	if l.iterating > 0 {
+		l.dirty = true
+		return
+	}
+	l.compact()
+}
+
+// 移除所有被标记删除的回调
+func (l *callbackList[F]) compact() {
+	l.entries = slices.DeleteFunc(l.entries, func(entry *callbackEntry[F]) bool {
+		return entry.removed
+	})
+	l.dirty = false
+}
+
+// 移除第一个满足match的回调，返回是否找到
+func (l *callbackList[F]) removeFunc(match func(callback F) bool) bool {
+	for _, entry := range l.entries {
+		if !entry.removed && match(entry.callback) {
+			l.remove(entry)
+			return true
+		}
+	}
+	return false
+}
+
+// 按优先级遍历所有有效的回调
+func (l *callbackList[F]) foreach(f func(callback F)) {
+	l.iterating++
+	defer func() {
+		l.iterating--
+		if l.iterating == 0 && l.dirty {
+			l.compact()
+		}
+	}()
+	// 遍历期间新增的回调会生成新的slice，不在本次遍历的范围内；
+	// 整理（compact）只在所有遍历结束后进行，所以这里的slice不会被修改
+	entries := l.entries
+	for _, entry := range entries {
+		if !entry.removed {
+			f(entry.callback)
+		}
+	}
+}
 
 type Delegate struct {
-	callbacks []func(Entity)
+	callbacks callbackList[func(Entity)]
+}
+
+// AddCallback 注册回调，返回的Subscription用于取消注册
+func (d *Delegate) AddCallback(callback func(Entity)) Subscription {
+	return d.callbacks.add(callback)
 }
 
-func (d *Delegate) AddCallback(callback func(Entity)) {
-	d.callbacks = append(d.callbacks, callback)
+// AddCallbackWithPriority 按优先级注册回调，priority越小越先被调用，
+// 相同优先级的回调按注册顺序调用。AddCallback的优先级为PriorityDefault。
+func (d *Delegate) AddCallbackWithPriority(callback func(Entity), priority int) Subscription {
+	return d.callbacks.addWithPriority(callback, priority)
 }
 
+// Deprecated: 通过函数指针比较回调，对于同一个函数字面量创建的不同闭包无法区分，
+// 可能会移除错误的回调，请使用AddCallback返回的Subscription取消注册。
 func (d *Delegate) RemoveCallback(callback func(Entity)) {
 	pf := reflect.ValueOf(callback).Pointer()
-	for i, cb := range d.callbacks {
-		if reflect.ValueOf(cb).Pointer() == pf {
-			d.callbacks = append(d.callbacks[:i], d.callbacks[i+1:]...)
-			break
-		}
-	}
+	d.callbacks.removeFunc(func(cb func(Entity)) bool {
+		return reflect.ValueOf(cb).Pointer() == pf
+	})
 }
 
 func (d *Delegate) Invoke(entity Entity) {
-	for _, cb := range d.callbacks {
+	d.callbacks.foreach(func(cb func(Entity)) {
 		cb(entity)
-	}
+	})
 }
 
 type DelegateWithParam struct {
-	callbacks []func(Entity, ...any)
+	callbacks callbackList[func(Entity, ...any)]
+}
+
+// AddCallback 注册回调，返回的Subscription用于取消注册
+func (d *DelegateWithParam) AddCallback(callback func(Entity, ...any)) Subscription {
+	return d.callbacks.add(callback)
 }
 
-func (d *DelegateWithParam) AddCallback(callback func(Entity, ...any)) {
-	d.callbacks = append(d.callbacks, callback)
+// AddCallbackWithPriority 按优先级注册回调，参考Delegate.AddCallbackWithPriority
+func (d *DelegateWithParam) AddCallbackWithPriority(callback func(Entity, ...any), priority int) Subscription {
+	return d.callbacks.addWithPriority(callback, priority)
 }
 
+// Deprecated: 与Delegate.RemoveCallback存在相同的问题，请使用AddCallback返回的Subscription取消注册。
 func (d *DelegateWithParam) RemoveCallback(callback func(Entity, ...any)) {
 	pf := reflect.ValueOf(callback).Pointer()
-	for i, cb := range d.callbacks {
-		if reflect.ValueOf(cb).Pointer() == pf {
-			d.callbacks = append(d.callbacks[:i], d.callbacks[i+1:]...)
-			break
-		}
-	}
+	d.callbacks.removeFunc(func(cb func(Entity, ...any)) bool {
+		return reflect.ValueOf(cb).Pointer() == pf
+	})
 }
 
 func (d *DelegateWithParam) Invoke(entity Entity, params ...any) {
-	for _, cb := range d.callbacks {
+	d.callbacks.foreach(func(cb func(Entity, ...any)) {
 		cb(entity, params...)
-	}
+	})
 }
 
 //与Entity有关的各种事件，可以用来监听entity的数据变化。
@@ -65,6 +199,17 @@ type EntityEvents struct {
 	AfterAddWithPoolIdx  DelegateWithParam
 }
 
+// World级的事件，用于监听entity的生命周期
+type WorldEvents struct {
+	// entity创建后触发。
+	// 对于Instantiate、SpawnBatch、Clone等方式创建的entity，会在所有组件添加完成后触发。
+	EntityCreated Delegate
+	// entity开始销毁时触发，此时entity的所有组件都还可以读取，但IsAlive已经返回 false。
+	EntityDestroying Delegate
+	// entity的所有组件都删除后、EntityData回收前触发，此时entity已经没有任何组件。
+	EntityDestroyed Delegate
+}
+
 type FilterEventListener interface {
 	OnEntityAdded(entity Entity)
 	OnEntityRemoved(entity Entity)
@@ -0,0 +1,116 @@
+package ecs
+
+import "reflect"
+
+// 类型擦除后的事件总线，方便World统一管理
+type iEventBus interface {
+	update()
+}
+
+// eventBus 某一种事件类型T在World中的事件总线。
+// 事件以双缓冲的方式存储：prev是上一帧的事件，curr是当前帧的事件，
+// 每次World.UpdateEvents时交换缓冲区，所以每个事件可以被EventReader读取两帧。
+type eventBus[T any] struct {
+	// 事件订阅者，Emit时立即同步回调
+	subscribers callbackList[func(T)]
+	prev        []T
+	curr        []T
+	// prev中第一个事件的序号，事件序号从0开始单调递增
+	prevStart int
+	// curr中第一个事件的序号
+	currStart int
+}
+
+// 交换缓冲区：当前帧的事件变为上一帧的事件，上一帧的事件被丢弃
+func (b *eventBus[T]) update() {
+	clear(b.prev)
+	b.prev, b.curr = b.curr, b.prev[:0]
+	b.prevStart = b.currStart
+	b.currStart += len(b.prev)
+}
+
+// 获取事件类型T在world中的事件总线，不存在则创建
+func getEventBus[T any](w *World) *eventBus[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	bus, ok := w.eventBuses[t]
+	if !ok {
+		bus = &eventBus[T]{}
+		w.eventBuses[t] = bus
+	}
+	return bus.(*eventBus[T])
+}
+
+// Emit 在world中发布一个事件ev。
+// 事件会先被缓存，供EventReader[T]在本帧和下一帧读取，然后所有通过Subscribe订阅的回调会被立即调用。
+// 先缓存再回调，保证回调中发布的同类型事件排在ev之后，EventReader读取到的顺序与发布的顺序一致。
+func Emit[T any](w *World, ev T) {
+	bus := getEventBus[T](w)
+	bus.curr = append(bus.curr, ev)
+	bus.subscribers.foreach(func(subscriber func(T)) {
+		subscriber(ev)
+	})
+}
+
+// Subscribe 订阅world中类型为T的事件，每次Emit时fn会被立即调用。
+// 返回的Subscription用于取消订阅。
+func Subscribe[T any](w *World, fn func(ev T)) Subscription {
+	bus := getEventBus[T](w)
+	return bus.subscribers.add(fn)
+}
+
+// UpdateEvents 交换所有事件总线的缓冲区，通常在每帧（tick）结束时调用。
+// 一个事件在发布的当帧和下一帧都可以被EventReader读取，之后会被丢弃。
+func (w *World) UpdateEvents() {
+	for _, bus := range w.eventBuses {
+		bus.update()
+	}
+}
+
+// EventReader 按帧读取world中类型为T的事件，每个EventReader独立记录自己的读取进度，
+// 适合在系统中使用：每个系统持有自己的EventReader，每帧读取一次，不会重复读取同一个事件。
+type EventReader[T any] struct {
+	bus *eventBus[T]
+	// 下一个要读取的事件序号
+	next int
+}
+
+// NewEventReader 创建一个读取world中类型为T的事件的EventReader，
+// 它可以读取到创建时仍然缓存着的事件（上一帧和本帧发布的事件）。
+func NewEventReader[T any](w *World) *EventReader[T] {
+	bus := getEventBus[T](w)
+	return &EventReader[T]{
+		bus:  bus,
+		next: bus.prevStart,
+	}
+}
+
+// Read 按发布顺序读取所有未读的事件。
+// 如果有事件在被读取之前就已经被丢弃（超过两帧没有读取），这些事件会被跳过。
+func (r *EventReader[T]) Read(f func(ev T)) {
+	bus := r.bus
+	if r.next < bus.prevStart {
+		r.next = bus.prevStart
+	}
+	for r.next < bus.currStart {
+		ev := bus.prev[r.next-bus.prevStart]
+		r.next++
+		f(ev)
+	}
+	// 回调中可能会发布新的事件，所以每次都重新判断长度
+	for r.next < bus.currStart+len(bus.curr) {
+		ev := bus.curr[r.next-bus.currStart]
+		r.next++
+		f(ev)
+	}
+}
+
+// Len 返回未读的事件数量
+func (r *EventReader[T]) Len() int {
+	next := max(r.next, r.bus.prevStart)
+	return r.bus.currStart + len(r.bus.curr) - next
+}
+
+// Clear 将所有未读的事件标记为已读
+func (r *EventReader[T]) Clear() {
+	r.next = r.bus.currStart + len(r.bus.curr)
+}
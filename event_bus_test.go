@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type eventBusTestEvent struct {
+	V int
+}
+
+func readAll[T any](r *EventReader[T]) []T {
+	var events []T
+	r.Read(func(ev T) {
+		events = append(events, ev)
+	})
+	return events
+}
+
+func TestEmitNestedOrder(t *testing.T) {
+	world := NewWorld()
+	reader := NewEventReader[eventBusTestEvent](world)
+	var dispatched []int
+	Subscribe(world, func(ev eventBusTestEvent) {
+		dispatched = append(dispatched, ev.V)
+		// 回调中发布同类型的事件
+		if ev.V == 1 {
+			Emit(world, eventBusTestEvent{V: 2})
+		}
+	})
+	Emit(world, eventBusTestEvent{V: 1})
+	Emit(world, eventBusTestEvent{V: 3})
+	want := []eventBusTestEvent{{V: 1}, {V: 2}, {V: 3}}
+	if got := readAll(reader); !slices.Equal(got, want) {
+		t.Errorf("read = %v, want %v", got, want)
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(dispatched, want) {
+		t.Errorf("dispatched = %v, want %v", dispatched, want)
+	}
+}
+
+func TestEventReaderDoubleBuffer(t *testing.T) {
+	tests := []struct {
+		name string
+		// 每帧发布的事件，帧之间调用UpdateEvents
+		frames [][]int
+		// 每帧发布事件之后是否读取
+		readAt []bool
+		want   [][]int
+	}{
+		{
+			name:   "read every frame",
+			frames: [][]int{{1, 2}, {3}, {4}},
+			readAt: []bool{true, true, true},
+			want:   [][]int{{1, 2}, {3}, {4}},
+		},
+		{
+			name:   "read every other frame",
+			frames: [][]int{{1}, {2}, {3}, {4}},
+			readAt: []bool{false, true, false, true},
+			want:   [][]int{nil, {1, 2}, nil, {3, 4}},
+		},
+		{
+			name:   "events older than two frames are dropped",
+			frames: [][]int{{1}, {2}, {3}},
+			readAt: []bool{false, false, true},
+			want:   [][]int{nil, nil, {2, 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			reader := NewEventReader[eventBusTestEvent](world)
+			for i, frame := range tt.frames {
+				if i > 0 {
+					world.UpdateEvents()
+				}
+				for _, v := range frame {
+					Emit(world, eventBusTestEvent{V: v})
+				}
+				if !tt.readAt[i] {
+					continue
+				}
+				if n := reader.Len(); n != len(tt.want[i]) {
+					t.Errorf("frame %d: Len = %d, want %d", i, n, len(tt.want[i]))
+				}
+				var got []int
+				for _, ev := range readAll(reader) {
+					got = append(got, ev.V)
+				}
+				if !slices.Equal(got, tt.want[i]) {
+					t.Errorf("frame %d: read = %v, want %v", i, got, tt.want[i])
+				}
+				if n := reader.Len(); n != 0 {
+					t.Errorf("frame %d: Len after read = %d, want 0", i, n)
+				}
+			}
+		})
+	}
+}
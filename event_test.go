@@ -0,0 +1,205 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDelegateMutationDuringInvoke(t *testing.T) {
+	tests := []struct {
+		name string
+		// 注册回调，回调被调用时将自己的名字追加到log中
+		setup func(d *Delegate, log *[]string)
+		// 两次Invoke的调用记录
+		want [][]string
+	}{
+		{
+			name: "priority order",
+			setup: func(d *Delegate, log *[]string) {
+				d.AddCallback(func(Entity) { *log = append(*log, "a") })
+				d.AddCallbackWithPriority(func(Entity) { *log = append(*log, "high") }, -1)
+				d.AddCallback(func(Entity) { *log = append(*log, "b") })
+				d.AddCallbackWithPriority(func(Entity) { *log = append(*log, "internal") }, PriorityInternal)
+				d.AddCallbackWithPriority(func(Entity) { *log = append(*log, "low") }, 1)
+			},
+			want: [][]string{{"internal", "high", "a", "b", "low"}, {"internal", "high", "a", "b", "low"}},
+		},
+		{
+			name: "unsubscribe self",
+			setup: func(d *Delegate, log *[]string) {
+				var sub Subscription
+				sub = d.AddCallback(func(Entity) {
+					*log = append(*log, "a")
+					sub.Unsubscribe()
+				})
+				d.AddCallback(func(Entity) { *log = append(*log, "b") })
+			},
+			want: [][]string{{"a", "b"}, {"b"}},
+		},
+		{
+			name: "unsubscribe later callback",
+			setup: func(d *Delegate, log *[]string) {
+				var sub Subscription
+				d.AddCallback(func(Entity) {
+					*log = append(*log, "a")
+					sub.Unsubscribe()
+				})
+				sub = d.AddCallback(func(Entity) { *log = append(*log, "b") })
+				d.AddCallback(func(Entity) { *log = append(*log, "c") })
+			},
+			want: [][]string{{"a", "c"}, {"a", "c"}},
+		},
+		{
+			name: "add during invoke",
+			setup: func(d *Delegate, log *[]string) {
+				added := false
+				d.AddCallback(func(Entity) {
+					*log = append(*log, "a")
+					if !added {
+						added = true
+						// 无论优先级，本次遍历都不会调用新增的回调
+						d.AddCallbackWithPriority(func(Entity) { *log = append(*log, "first") }, -1)
+						d.AddCallback(func(Entity) { *log = append(*log, "last") })
+					}
+				})
+				d.AddCallback(func(Entity) { *log = append(*log, "b") })
+			},
+			want: [][]string{{"a", "b"}, {"first", "a", "b", "last"}},
+		},
+		{
+			name: "nested invoke with unsubscribe",
+			setup: func(d *Delegate, log *[]string) {
+				depth := 0
+				var sub Subscription
+				d.AddCallback(func(e Entity) {
+					*log = append(*log, "a")
+					if depth == 0 {
+						depth++
+						// 内层遍历中取消订阅b，外层遍历也不会再调用b
+						sub.Unsubscribe()
+						d.Invoke(e)
+						depth--
+					}
+				})
+				sub = d.AddCallback(func(Entity) { *log = append(*log, "b") })
+				d.AddCallback(func(Entity) { *log = append(*log, "c") })
+			},
+			want: [][]string{{"a", "a", "c", "c"}, {"a", "a", "c", "c"}},
+		},
+		{
+			name: "unsubscribe twice",
+			setup: func(d *Delegate, log *[]string) {
+				var sub Subscription
+				sub = d.AddCallback(func(Entity) {
+					*log = append(*log, "a")
+					sub.Unsubscribe()
+					sub.Unsubscribe()
+				})
+				d.AddCallback(func(Entity) { *log = append(*log, "b") })
+				// 零值的Subscription也可以取消订阅
+				Subscription{}.Unsubscribe()
+			},
+			want: [][]string{{"a", "b"}, {"b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Delegate
+			var log []string
+			tt.setup(&d, &log)
+			for round, want := range tt.want {
+				log = log[:0]
+				d.Invoke(Entity{})
+				if !slices.Equal(log, want) {
+					t.Errorf("round %d: got %v, want %v", round, log, want)
+				}
+			}
+			if d.callbacks.iterating != 0 || d.callbacks.dirty {
+				t.Errorf("callbackList is not compacted: iterating %d, dirty %v", d.callbacks.iterating, d.callbacks.dirty)
+			}
+		})
+	}
+}
+
+func TestUnsubscribeTwice(t *testing.T) {
+	type target struct {
+		// 注册一个回调，回调被调用时将name追加到log中
+		subscribe func(name string, log *[]string) Subscription
+		// 触发一次事件
+		fire func()
+	}
+	tests := []struct {
+		name      string
+		newTarget func() target
+	}{
+		{
+			name: "Delegate",
+			newTarget: func() target {
+				var d Delegate
+				return target{
+					subscribe: func(name string, log *[]string) Subscription {
+						return d.AddCallback(func(Entity) { *log = append(*log, name) })
+					},
+					fire: func() { d.Invoke(Entity{}) },
+				}
+			},
+		},
+		{
+			name: "DelegateWithParam",
+			newTarget: func() target {
+				var d DelegateWithParam
+				return target{
+					subscribe: func(name string, log *[]string) Subscription {
+						return d.AddCallback(func(Entity, ...any) { *log = append(*log, name) })
+					},
+					fire: func() { d.Invoke(Entity{}, 1) },
+				}
+			},
+		},
+		{
+			name: "filter OnAdd",
+			newTarget: func() target {
+				world := NewWorld()
+				filter := RegisterFilter(world, NewFilter1[worldTestHp](world))
+				return target{
+					subscribe: func(name string, log *[]string) Subscription {
+						return filter.OnAdd(func(Entity) { *log = append(*log, name) })
+					},
+					fire: func() { Replace(world.NewEntity(), worldTestHp{}) },
+				}
+			},
+		},
+		{
+			name: "filter AddListener",
+			newTarget: func() target {
+				world := NewWorld()
+				filter := RegisterFilter(world, NewFilter1[worldTestHp](world))
+				return target{
+					subscribe: func(name string, log *[]string) Subscription {
+						listener := newFilterEventListener()
+						listener.EntityAdded.AddCallback(func(Entity) { *log = append(*log, name) })
+						return filter.AddListener(listener)
+					},
+					fire: func() { Replace(world.NewEntity(), worldTestHp{}) },
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.newTarget()
+			var log []string
+			a := target.subscribe("a", &log)
+			target.subscribe("b", &log)
+			a.Unsubscribe()
+			// 再次取消订阅不会移除其他回调
+			a.Unsubscribe()
+			target.subscribe("c", &log)
+			a.Unsubscribe()
+			target.fire()
+			if want := []string{"b", "c"}; !slices.Equal(log, want) {
+				t.Errorf("got %v, want %v", log, want)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
-import ecs "github.com/Lei2050/go-ecs"
+import (
+	"fmt"
+
+	ecs "github.com/Lei2050/go-ecs"
+)
 
 func init() {
 	ecs.RegisterComponentType[FlyComponent](4)
@@ -24,6 +28,15 @@ type IdCardComponent struct {
 
 var _ ecs.IGroupKeyMap[IdCardComponent, ecs.Multi3Key[int, uint64, string]] = IdCardComponent{}
 
+// 组件实现OnAdd/OnRemove方法后，添加/删除组件时会被自动调用，不需要额外注册事件
+func (c *IdCardComponent) OnAdd(e ecs.Entity) {
+	fmt.Printf("    EVENT: add IdCardComponent entity:%+v, idCard:%+v\n", e, *c)
+}
+
+func (c *IdCardComponent) OnRemove(e ecs.Entity) {
+	fmt.Printf("    EVENT: remove IdCardComponent entity:%+v, idCard:%+v\n", e, *c)
+}
+
 func (IdCardComponent) MapKey(id IdCardComponent) ecs.Multi3Key[int, uint64, string] {
 	return ecs.Multi3Key[int, uint64, string]{Key1: id.Id, Key2: uint64(id.Security), Key3: id.Province}
 }
@@ -85,47 +98,3 @@ func (AgeComponent) MapKey(age AgeComponent) int {
 
 // super man
 type ImmortalComponent struct{}
-
-/*
-	BeforeAdd Delegate
-	AfterAdd  Delegate
-
-	BeforeUpdate Delegate
-
-	BeforeDelete Delegate
-	AfterDelete  Delegate
-
-	BeforeAddWithPoolIdx DelegateWithParam
-	AfterAddWithPoolIdx  DelegateWithParam
-*/
-func registerCompChangeEvent[T any](beforeAdd func(ecs.Entity),
-	afterAdd func(ecs.Entity),
-	beforeUpdate func(ecs.Entity),
-	beforeDelete func(ecs.Entity),
-	afterDelete func(ecs.Entity),
-	beforeAddWithPoolIdx func(ecs.Entity, ...any),
-	afterAddWithPoolIdx func(ecs.Entity, ...any),
-) {
-	componentType := ecs.GetComponentType[T]()
-	if beforeAdd != nil {
-		componentType.Events.BeforeAdd.AddCallback(beforeAdd)
-	}
-	if afterAdd != nil {
-		componentType.Events.AfterAdd.AddCallback(afterAdd)
-	}
-	if beforeUpdate != nil {
-		componentType.Events.BeforeUpdate.AddCallback(beforeUpdate)
-	}
-	if beforeDelete != nil {
-		componentType.Events.BeforeDelete.AddCallback(beforeDelete)
-	}
-	if afterDelete != nil {
-		componentType.Events.AfterDelete.AddCallback(afterDelete)
-	}
-	if beforeAddWithPoolIdx != nil {
-		componentType.Events.BeforeAddWithPoolIdx.AddCallback(beforeAddWithPoolIdx)
-	}
-	if afterAddWithPoolIdx != nil {
-		componentType.Events.AfterAddWithPoolIdx.AddCallback(afterAddWithPoolIdx)
-	}
-}
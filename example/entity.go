@@ -2,26 +2,37 @@ package main
 
 import ecs "github.com/Lei2050/go-ecs"
 
+var (
+	humanPrefab *ecs.Prefab
+	birdPrefab  *ecs.Prefab
+	fishPrefab  *ecs.Prefab
+)
+
+// 初始化实体模板，需要在组件注册之后调用
+func initPrefabs() {
+	humanPrefab = ecs.NewPrefab(IdCardComponent{}, GenderComponent{}, NameComponent{}, AgeComponent{}, WalkComponent{})
+	birdPrefab = ecs.NewPrefab(FlyComponent{}, KindComponent{})
+	//模板也可以从数据中加载
+	var err error
+	fishPrefab, err = ecs.LoadPrefabJSON([]byte(`{"KindComponent": {"Name": "fish"}, "BreathInWaterComponent": {}}`))
+	if err != nil {
+		panic(err)
+	}
+}
+
 func spawnHuman(world *ecs.World, id int, safeNum uint64, code string, gender int, name string, age int) ecs.Entity {
-	people := world.NewEntity()
-	ecs.Replace(people, IdCardComponent{Id: id, Security: safeNum, Province: code})
-	ecs.Replace(people, GenderComponent{Val: gender})
-	ecs.Replace(people, NameComponent{First: name})
-	ecs.Replace(people, AgeComponent{Val: age})
-	ecs.Replace(people, WalkComponent{})
-	return people
+	return world.Instantiate(humanPrefab,
+		IdCardComponent{Id: id, Security: safeNum, Province: code},
+		GenderComponent{Val: gender},
+		NameComponent{First: name},
+		AgeComponent{Val: age},
+	)
 }
 
 func spawnBird(world *ecs.World, kind string) ecs.Entity {
-	bird := world.NewEntity()
-	ecs.Replace(bird, FlyComponent{})
-	ecs.Replace(bird, KindComponent{Name: kind})
-	return bird
+	return world.Instantiate(birdPrefab, KindComponent{Name: kind})
 }
 
 func spawnFish(world *ecs.World, kind string) ecs.Entity {
-	fish := world.NewEntity()
-	ecs.Replace(fish, KindComponent{Name: kind})
-	ecs.Replace(fish, BreathInWaterComponent{})
-	return fish
+	return world.Instantiate(fishPrefab, KindComponent{Name: kind})
 }
@@ -2,6 +2,9 @@ package main
 
 import "github.com/Lei2050/go-ecs"
 
+// 正常人按省份分组的过滤器名称
+const humanByProvince = "humanByProvince"
+
 func initFilters(world *ecs.World) {
 	ecs.RegisterFilter(world, ecs.NewFilter4Exclude4[GenderComponent, NameComponent, WalkComponent, AgeComponent, FlyComponent, KindComponent, SwimComponent, BreathInWaterComponent](world))
 	ecs.RegisterFilter(world, ecs.NewFilter2Exclude1[FlyComponent, KindComponent, SwimComponent](world))
@@ -16,8 +19,13 @@ func initFilters(world *ecs.World) {
 	ecs.RegisterFilter(world, ecs.NewFilter2[AgeComponent, NameComponent](world))
 	ecs.RegisterGroupFilter(world, ecs.NewGroupFilter2WithKeyMapper[AgeComponent, NameComponent, int, string, AgeComponent, NameComponent](world))
 
-	ecs.RegisterFilter(world, ecs.NewFilter3Exclude2[IdCardComponent, NameComponent, AgeComponent, FlyComponent, BreathInWaterComponent](world))
-	ecs.RegisterGroupFilter(world, ecs.NewGroupFilterWithKeyMapper[IdCardComponent, int, IdCardGroupIdMapper](world))
+	humanFilter := ecs.RegisterFilter(world, ecs.NewFilter3Exclude2[IdCardComponent, NameComponent, AgeComponent, FlyComponent, BreathInWaterComponent](world))
+	//正常人（不会飞、不能在水里呼吸）按省份分组
+	ecs.RegisterGroupFilterNamed(world, humanByProvince, ecs.NewGroupFilterFunc(world, humanFilter, func(entity ecs.Entity) string {
+		return ecs.Get[IdCardComponent](entity).Province
+	}, ecs.GetComponentType[IdCardComponent]()))
+	//身份证号是唯一的，重复时直接panic
+	ecs.RegisterGroupFilter(world, ecs.NewUniqueGroupFilterWithKeyMapper[IdCardComponent, int, IdCardGroupIdMapper](world, ecs.UniqueConflictPanic))
 	ecs.RegisterFilter(world, ecs.NewFilter2[IdCardComponent, GenderComponent](world))
 	ecs.RegisterGroupFilter(world, ecs.NewGroupFilter2WithKeyMapper[IdCardComponent, GenderComponent, string, int, IdCardGroupProvinceMapper, GenderGroupMapper](world))
 }
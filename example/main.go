@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"iter"
 
 	ecs "github.com/Lei2050/go-ecs"
 )
@@ -10,6 +11,7 @@ func main() {
 	//component.init()
 	world := ecs.NewWorld()
 	initFilters(world)
+	initPrefabs()
 
 	//筛选所有正常人，有身份证、名字、年龄，但不会飞、不能在水里呼吸
 	humanFilter := ecs.GetFilter[*ecs.Filter3Exclude2[IdCardComponent, NameComponent, AgeComponent, FlyComponent, BreathInWaterComponent]](world)
@@ -21,33 +23,16 @@ func main() {
 		fmt.Printf("    -human entity:%+v add, id:%+v, name:%+v\n", entity, idCard, name)
 	})
 
-	//监听IdCardComponent变化
-	registerCompChangeEvent[IdCardComponent](func(e ecs.Entity) {
-		fmt.Printf("    EVENT: beforeAdd IdCardComponent entity:%+v change\n", e)
-	},
-		nil,
-		//func(e ecs.Entity) {
-		//	fmt.Printf("    EVENT: afterAdd IdCardComponent entity:%+v change\n", e)
-		//},
-		func(e ecs.Entity) {
-			fmt.Printf("    EVENT: beforeUpdate IdCardComponent entity:%+v change\n", e)
-		},
-		func(e ecs.Entity) {
-			fmt.Printf("    EVENT: beforeDelete IdCardComponent entity:%+v change\n", e)
-		},
-		func(e ecs.Entity) {
-			fmt.Printf("    EVENT: afterDelete IdCardComponent entity:%+v change\n", e)
-		},
-		//func(e ecs.Entity, params ...any) {
-		//	compPoolIdx := params[0].(int)
-		//	fmt.Printf("    EVENT: beforeAddWithPoolIdx IdCardComponent entity:%+v change, compPoolIdx:%+v\n", e, compPoolIdx)
-		//},
-		nil,
-		func(e ecs.Entity, params ...any) {
-			compPoolIdx := params[0].(int)
-			fmt.Printf("    EVENT: afterAddWithPoolIdx IdCardComponent entity:%+v change, compPoolIdx:%+v\n", e, compPoolIdx)
-		},
-	)
+	//筛选所有成年的entity，年龄变化时自动重新判断
+	adultFilter := ecs.NewPredicateFilter(ecs.GetFilter[*ecs.Filter2[AgeComponent, NameComponent]](world), func(entity ecs.Entity) bool {
+		return ecs.Get[AgeComponent](entity).Val >= 18
+	}, ecs.GetComponentType[AgeComponent]())
+
+	//IdCardComponent的添加/删除通过组件自身的OnAdd/OnRemove方法监听
+	//类型化的组件钩子，回调中直接携带组件数据，不需要再Get
+	ecs.OnReplace(world, func(e ecs.Entity, oldIdCard, newIdCard *IdCardComponent) {
+		fmt.Printf("    HOOK: replace IdCardComponent entity:%+v, %+v -> %+v\n", e, *oldIdCard, *newIdCard)
+	})
 
 	//生成entity，并添加组件
 	leilei := spawnHuman(world, 9527, 123456, "浙江", 0, "雷雷", 25)
@@ -107,20 +92,20 @@ func main() {
 	fmt.Println("---------------------")
 
 	//检索身份证id为9381的人
-	idGroupFilter := ecs.GetGroupFilter[*ecs.GroupFilterWithKeyMapper[IdCardComponent, int, IdCardGroupIdMapper]](world)
-	entity, ok := idGroupFilter.FindOne(9381)
+	idGroupFilter := ecs.GetGroupFilter[*ecs.UniqueGroupFilterWithKeyMapper[IdCardComponent, int, IdCardGroupIdMapper]](world)
+	entity, ok := idGroupFilter.Get(9381)
 	if ok {
 		fmt.Printf("entity:%+v, human, id:%+v, name:%+v, age:%+v\n", entity, ecs.Get[IdCardComponent](entity), ecs.Get[NameComponent](entity), ecs.Get[AgeComponent](entity))
 	}
 	fmt.Println("after changing xx's id ---------------------")
 	ecs.Replace(xx, IdCardComponent{9681, 567890, "湖北"})
-	entity, ok = idGroupFilter.FindOne(9381)
+	entity, ok = idGroupFilter.Get(9381)
 	if ok {
 		fmt.Printf("entity:%+v, human, id:%+v, name:%+v, age:%+v\n", entity, ecs.Get[IdCardComponent](entity), ecs.Get[NameComponent](entity), ecs.Get[AgeComponent](entity))
 	} else {
 		fmt.Printf("not find id=9381\n")
 	}
-	entity, ok = idGroupFilter.FindOne(9681)
+	entity, ok = idGroupFilter.Get(9681)
 	if ok {
 		fmt.Printf("entity:%+v, human, id:%+v, name:%+v, age:%+v\n", entity, ecs.Get[IdCardComponent](entity), ecs.Get[NameComponent](entity), ecs.Get[AgeComponent](entity))
 	} else {
@@ -134,6 +119,20 @@ func main() {
 			entity, ecs.Get[IdCardComponent](entity), ecs.Get[GenderComponent](entity), ecs.Get[NameComponent](entity), ecs.Get[AgeComponent](entity))
 	})
 
+	fmt.Println("---------------------")
+	//统计每个省份、性别的人数
+	xxGroupFilter.ForeachGroup(func(key ecs.Multi2Key[string, int], entities iter.Seq[ecs.Entity]) {
+		fmt.Printf("province:%s, gender:%d, count:%d\n", key.Key1, key.Key2, xxGroupFilter.Count(key))
+	})
+
+	//统计每个省份的正常人数，鸟人、鱼人不算
+	humanByProvinceFilter := ecs.GetGroupFilterNamed[*ecs.GroupFilterFunc[string]](world, humanByProvince)
+	for _, province := range humanByProvinceFilter.Keys() {
+		fmt.Printf("province:%s, human count:%d\n", province, humanByProvinceFilter.Count(province))
+	}
+
+	fmt.Printf("adult count:%d\n", adultFilter.Len())
+
 	fmt.Println("going to destroy ---------------------")
 	leilei.Destroy()
 	ecs.Del[IdCardComponent](xx2)
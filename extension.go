@@ -5,6 +5,18 @@ import (
 	"reflect"
 )
 
+// checkEntityAlive 用于检查Entity的有效性、是否存活，
+// 返回值分别为Entity所在的世界实例指针和Entity数据指针。
+func checkEntityAlive(entity Entity) (*World, *EntityData) {
+	world := entity.World()
+	entityData := world.getEntityData(entity.Id)
+	if !entityData.isCurrentEntityData(entity) {
+		//不允许使用非存活的entity
+		panic("entity is not alive")
+	}
+	return world, entityData
+}
+
 // checkEntity 用于检查Entity的有效性、是否存活，
 // 返回值分别为Entity所在的世界实例指针、Entity数据指针和组件类型指针。
 func checkEntity[T any](entity Entity) (*World, *EntityData, *ComponentType) {
@@ -36,6 +48,7 @@ func Has[T any](entity Entity) bool {
 }
 
 // Replace 用于附加/替换Entity的指定组件。
+// 正在销毁（包括已调用QueueDestroy）的entity只能替换已有的组件，添加新的组件会被忽略。
 func Replace[T any](entity Entity, component T) {
 	world, entityData, componentType := checkEntity[T](entity)
 
@@ -53,16 +66,24 @@ func Replace[T any](entity Entity, component T) {
 			//只需要变更groupFilter中的集合数据。
 			//触发一下groupKey移除的事件，通知相关groupFilter移除entitty
 			world.fireGroupKeyEvent(componentType.TypeIndex, groupKeyRemove, entity)
+			// 有OnReplace钩子时，保存一份替换前的组件数据
+			old := world.snapshotForReplace(componentType.TypeIndex, comp)
 			// 替换组件数据
 			*comp = component
 			//触发一下groupKey增加的事件，通知相关groupFilter移除entitty
 			world.fireGroupKeyEvent(componentType.TypeIndex, groupKeyAdd, entity)
+			// 触发组件替换的钩子
+			world.fireComponentReplaced(componentType.TypeIndex, entity, old, comp)
 			return
 		}
 	}
 
 	//组件不存在
 
+	// 正在销毁的entity不能再添加新的组件，否则销毁时删除组件的循环可能永远无法结束
+	if entityData.IsDestroying {
+		return
+	}
 	pool := getComponentPool[T](world)
 	idx, data := pool.Alloc()
 	comp := data.(*T)
@@ -88,26 +109,132 @@ func applyComponent[T any](world *World, entity Entity, entityData *EntityData,
 	// 触发组件添加后的事件
 	componentType.Events.AfterAdd.Invoke(entity)
 	componentType.Events.AfterAddWithPoolIdx.Invoke(entity, compPoolIdx)
+	// 触发组件添加的钩子
+	world.fireComponentAdded(componentType, entity, compPoolIdx)
+}
+
+// applyComponents 用于批量地将组件应用到Entity上，Entity已经拥有的组件会被替换。
+// 与逐个调用Replace不同，它先写入所有组件数据，再统一更新一次相关过滤器，
+// 避免了Entity在中间状态下反复进出过滤器。
+// components中同一类型的组件出现多次时，以最后一个为准。
+// filters 是预先汇总好的相关过滤器，为 nil 时根据新增的组件自动汇总。
+func applyComponents(world *World, entity Entity, entityData *EntityData, components []componentValue, filters []IFilter) {
+	// 新增的组件及其在组件池中的索引
+	var added []componentValue
+	var addedPoolIndices []int
+	for _, c := range dedupComponents(components) {
+		componentType := c.componentType
+		pool := world.getOrCreateComponentPool(componentType)
+		if dataIdx, ok := entityData.CompIndices[componentType.TypeIndex]; ok {
+			// entity拥有该组件，与Replace一致，只替换组件数据
+			componentType.Events.BeforeUpdate.Invoke(entity)
+			world.fireGroupKeyEvent(componentType.TypeIndex, groupKeyRemove, entity)
+			comp := pool.GetRef(dataIdx)
+			old := world.snapshotForReplace(componentType.TypeIndex, comp)
+			componentType.assign(comp, c.value)
+			world.fireGroupKeyEvent(componentType.TypeIndex, groupKeyAdd, entity)
+			world.fireComponentReplaced(componentType.TypeIndex, entity, old, comp)
+			continue
+		}
+		// 与Replace一致，正在销毁的entity不能再添加新的组件
+		if entityData.IsDestroying {
+			continue
+		}
+		idx, data := pool.Alloc()
+		componentType.assign(data, c.value)
+		entityData.CompFlags |= componentType.Flag
+		entityData.CompIndices[componentType.TypeIndex] = idx
+		added = append(added, c)
+		addedPoolIndices = append(addedPoolIndices, idx)
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	for i, c := range added {
+		c.componentType.Events.BeforeAdd.Invoke(entity)
+		c.componentType.Events.BeforeAddWithPoolIdx.Invoke(entity, addedPoolIndices[i])
+	}
+	if filters == nil {
+		filters = world.collectFilters(componentTypeIndices(added))
+	}
+	// 所有组件都已写入，world统一通知相关过滤器执行一次更新
+	world.updateFiltersAfterBatchAdd(filters, entity, entityData)
+	for i, c := range added {
+		c.componentType.Events.AfterAdd.Invoke(entity)
+		c.componentType.Events.AfterAddWithPoolIdx.Invoke(entity, addedPoolIndices[i])
+		world.fireComponentAdded(c.componentType, entity, addedPoolIndices[i])
+	}
+}
+
+// 对components去重，同一类型的组件以最后一个为准，保持组件首次出现的顺序
+func dedupComponents(components []componentValue) []componentValue {
+	result := make([]componentValue, 0, len(components))
+	pos := make(map[int]int, len(components)) //<typeIndex, 在result中的下标>
+	for _, c := range components {
+		if i, ok := pos[c.componentType.TypeIndex]; ok {
+			result[i] = c
+			continue
+		}
+		pos[c.componentType.TypeIndex] = len(result)
+		result = append(result, c)
+	}
+	return result
+}
+
+// 获取components的所有组件类型索引
+func componentTypeIndices(components []componentValue) []int {
+	typeIndices := make([]int, 0, len(components))
+	for _, c := range components {
+		typeIndices = append(typeIndices, c.componentType.TypeIndex)
+	}
+	return typeIndices
+}
+
+// AddComponents 批量地附加/替换Entity的多个组件，
+// components中的每个元素都必须是已注册的组件值（不能是指针），同一类型出现多次时以最后一个为准。
+// 与多次调用Replace不同，它先写入所有组件数据，再统一更新一次相关过滤器，
+// 每个受影响的过滤器只会被操作一次。与Replace一样，正在销毁的entity添加新的组件会被忽略。
+func AddComponents(entity Entity, components ...any) {
+	world, entityData := checkEntityAlive(entity)
+	values := make([]componentValue, 0, len(components))
+	for _, component := range components {
+		values = append(values, newComponentValue(component))
+	}
+	applyComponents(world, entity, entityData, values, nil)
 }
 
 // TryGet 尝试获取Entity的指定组件。
 // 返回值为组件指针和布尔类型，若获取成功则返回组件指针和 true，否则返回 nil 和 false。
 // 注意，不要长期持有返回的指针，指向的对象可能频繁地被回收/变更/复用；
 // 注意，不要再堆上持有返回的指针，除非你明确了解其生命周期。
+// 注意，TryGet/Get用于只读，若要修改组件数据，请使用带ForWrite的版本，否则groupFilter不会更新索引。
 func TryGet[T any](entity Entity) (*T, bool) {
-	return TryGetMayForWrite[T](entity)
+	_, _, data, ok := tryGetComponent[T](entity)
+	return data, ok
 }
 
 // TryGetMayForWrite 尝试获取Entity的指定组件，可能用于写入操作。
 // 返回值为组件指针和布尔类型，若获取成功则返回组件指针和 true，否则返回 nil 和 false。
+// 若组件是某些groupFilter的key，entity会先从这些groupFilter中移除，
+// 在下一次查询groupFilter（或调用World.SyncGroupKeys）时按新的key重新加入。
 // 注意，不要长期持有返回的指针，指向的对象可能频繁地被回收/变更/复用；
 // 注意，不要再堆上持有返回的指针，除非你明确了解其生命周期。
 func TryGetMayForWrite[T any](entity Entity) (*T, bool) {
+	world, componentType, data, ok := tryGetComponent[T](entity)
+	if ok {
+		world.beginGroupKeyWrite(componentType.TypeIndex, entity)
+	}
+	return data, ok
+}
+
+// 获取Entity的指定组件，不触发任何事件
+func tryGetComponent[T any](entity Entity) (*World, *ComponentType, *T, bool) {
 	world, entityData, componentType := checkEntity[T](entity)
 
 	// 检查Entity是否拥有该组件
 	if entityData.CompFlags&componentType.Flag == 0 { //没有该comp
-		return nil, false
+		return world, componentType, nil, false
 	}
 
 	typeIndex := componentType.TypeIndex
@@ -115,47 +242,52 @@ func TryGetMayForWrite[T any](entity Entity) (*T, bool) {
 	if ok {
 		pool := getComponentPool[T](world)
 		data := pool.GetRef(dataIdx)
-		return data.(*T), true
+		return world, componentType, data.(*T), true
 	}
 
-	return nil, false
+	return world, componentType, nil, false
 }
 
 // Get 用于获取Entity的指定组件，其假定Entity拥有该组件；
 // 若Entity不拥有该组件则触发 panic。
+// 注意，Get用于只读，参考TryGet的注释。
 func Get[T any](entity Entity) *T {
-	return GetMayForWrite[T](entity)
+	_, _, data := getComponent[T](entity)
+	return data
 }
 
 // GetMayForWrite 用于获取Entity的指定组件，可能用于写入操作，其假定Entity拥有该组件；
 // 返回值为组件指针，若Entity不拥有该组件则触发 panic。
+// groupFilter索引的更新参考TryGetMayForWrite的注释。
 func GetMayForWrite[T any](entity Entity) *T {
-	world, entityData, componentType := checkEntity[T](entity)
-	dataIdx, ok := entityData.CompIndices[componentType.TypeIndex]
-	if !ok {
-		t := reflect.TypeOf((*T)(nil)).Elem().Elem()
-		panic(fmt.Sprintf("entity:%+v not has component:%s", entity, t.Name()))
-	}
-	pool := getComponentPool[T](world)
-	data := pool.GetRef(dataIdx)
-	return data.(*T)
+	world, componentType, data := getComponent[T](entity)
+	world.beginGroupKeyWrite(componentType.TypeIndex, entity)
+	return data
 }
 
 // GetForWrite 用于获取Entity的指定组件，用于写入操作。
 // 其假定Entity拥有该组件，并且假定用户调用后必定会修改该组件的数据。
 // 返回值为组件指针，若Entity不拥有该组件则触发 panic。
+// groupFilter索引的更新参考TryGetMayForWrite的注释。
 func GetForWrite[T any](entity Entity) *T {
+	world, componentType, data := getComponent[T](entity)
+	// 触发组件更新前的事件
+	componentType.Events.BeforeUpdate.Invoke(entity) //更新通知
+	world.beginGroupKeyWrite(componentType.TypeIndex, entity)
+	return data
+}
+
+// 获取Entity的指定组件，不触发任何事件，若Entity不拥有该组件则触发 panic
+func getComponent[T any](entity Entity) (*World, *ComponentType, *T) {
 	world, entityData, componentType := checkEntity[T](entity)
 	dataIdx, ok := entityData.CompIndices[componentType.TypeIndex]
 	if !ok {
 		t := reflect.TypeOf((*T)(nil)).Elem()
 		panic(fmt.Sprintf("entity:%+v not has component:%s", entity, t.Name()))
 	}
-	// 触发组件更新前的事件
-	componentType.Events.BeforeUpdate.Invoke(entity) //更新通知
 	pool := getComponentPool[T](world)
 	data := pool.GetRef(dataIdx)
-	return data.(*T)
+	return world, componentType, data.(*T)
 }
 
 // Ensure 确保Entity拥有指定组件。
@@ -165,16 +297,22 @@ func Ensure[T any](entity Entity) *T {
 }
 
 // EnsureMayForWrite 确保Entity拥有指定组件，可能用于写入操作。
-// 返回值为组件指针，若Entity没有该组件则添加并返回。
+// 返回值为组件指针，若Entity没有该组件则添加并返回；正在销毁的entity没有该组件时触发 panic。
 func EnsureMayForWrite[T any](entity Entity) *T {
 	world, entityData, componentType := checkEntity[T](entity)
 	dataIdx, ok := entityData.CompIndices[componentType.TypeIndex]
 	pool := getComponentPool[T](world)
 	if ok {
 		data := pool.GetRef(dataIdx)
+		world.beginGroupKeyWrite(componentType.TypeIndex, entity)
 		return data.(*T)
 	}
 
+	if entityData.IsDestroying {
+		// 正在销毁的entity不能再添加新的组件，参考Replace
+		t := reflect.TypeOf((*T)(nil)).Elem()
+		panic(fmt.Sprintf("entity:%+v is destroying, can not add component:%s", entity, t.Name()))
+	}
 	idx, data := pool.Alloc()
 	// 应用新的组件到Entity
 	applyComponent[T](world, entity, entityData, idx, componentType)
@@ -182,43 +320,53 @@ func EnsureMayForWrite[T any](entity Entity) *T {
 	return data.(*T)
 }
 
-// MarkDirty 标记Entity的指定组件为脏数据。
-// 若Entity拥有该组件，则触发组件更新前的事件。
+// MarkDirty 标记Entity的指定组件为脏数据，在修改组件数据之前或之后调用都可以。
+// 若Entity拥有该组件，则触发组件更新前的事件，groupFilter索引的更新参考TryGetMayForWrite的注释。
 func MarkDirty[T any](entity Entity) {
 	if !Has[T](entity) {
 		return
 	}
-	_, _, componentType := checkEntity[T](entity)
+	world, _, componentType := checkEntity[T](entity)
 	// 触发组件更新前的事件
 	componentType.Events.BeforeUpdate.Invoke(entity)
+	world.beginGroupKeyWrite(componentType.TypeIndex, entity)
 }
 
 // Del 用于删除Entity的指定组件。
 // 删除成功则返回 true，否则返回 false。
 func Del[T any](entity Entity) bool {
 	world, entityData, componentType := checkEntity[T](entity)
+	return removeComponent(world, entity, entityData, componentType)
+}
+
+// removeComponent 用于删除Entity的指定组件，Del和destroyEntity都通过它来删除组件，
+// 保证两者会同样地更新过滤器、触发组件删除前后的事件。
+// 删除成功则返回 true，否则返回 false。
+func removeComponent(world *World, entity Entity, entityData *EntityData, componentType *ComponentType) bool {
 	// 检查Entity是否拥有该组件
 	if entityData.CompFlags&componentType.Flag == 0 {
 		return false
 	}
 
 	gen := entityData.Gen
-	_, ok := entityData.CompIndices[componentType.TypeIndex]
+	compPoolIdx, ok := entityData.CompIndices[componentType.TypeIndex]
 	if !ok {
 		return false
 	}
 
 	// 组件删除，world通知相关过滤器执行更新
 	world.updateFiltersBeforeRemove(componentType.TypeIndex, entity, entityData)
+	// 触发组件删除的钩子，此时组件数据还未回收
+	world.fireComponentRemoved(componentType, entity, compPoolIdx)
 	// 触发组件删除前的事件
 	componentType.Events.BeforeDelete.Invoke(entity)
-	if gen != entity.Gen { //期间执行事件导致entity销毁过了？重复删除？
+	if gen != entityData.Gen { //期间执行事件导致entity销毁过了？重复删除？
 		return false
 	}
 	//重新寻找一下idx，防止前面的事件执行时改变了idx
-	compPoolIdx, ok := entityData.CompIndices[componentType.TypeIndex]
+	compPoolIdx, ok = entityData.CompIndices[componentType.TypeIndex]
 	if ok {
-		pool := getComponentPool[T](world)
+		pool := world.getComponentPoolByTypeIndex(componentType.TypeIndex)
 		pool.Free(compPoolIdx)
 		delete(entityData.CompIndices, componentType.TypeIndex)
 	}
@@ -228,6 +376,11 @@ func Del[T any](entity Entity) bool {
 }
 
 // destroyEntity 函数用于销毁指定的Entity。
+// 销毁流程如下：
+//  1. 触发World.Events.EntityDestroying事件，此时entity的所有组件都还可以读取；
+//  2. 断开父子关系、清理entity的所有关系；
+//  3. 逐个删除entity的组件，与Del的流程一致，会更新过滤器并触发组件删除前后的事件；
+//  4. 触发World.Events.EntityDestroyed事件，然后回收EntityData。
 func destroyEntity(entity Entity) {
 	world := entity.World()
 	entityData := world.getEntityData(entity.Id)
@@ -239,19 +392,35 @@ func destroyEntity(entity Entity) {
 	}
 	// 标记Entity为已销毁状态
 	entityData.IsDestroying = true
+	doDestroyEntity(world, entity, entityData)
+}
+
+// doDestroyEntity 执行destroyEntity中描述的销毁流程，调用前entity必须已经被标记为IsDestroying。
+// 延迟销毁的entity在World.FlushDestroyed时也通过它来销毁。
+func doDestroyEntity(world *World, entity Entity, entityData *EntityData) {
 	var saveEntity Entity
 	saveEntity.Id = entity.Id
 	saveEntity.Gen = entity.Gen
 	saveEntity.WorldPtr = entity.WorldPtr
 
-	// 遍历Entity的所有组件索引
-	for typeIndex, compPoolIdx := range entityData.CompIndices {
-		// 更新所有相关过滤器
-		world.updateFiltersBeforeRemove(typeIndex, saveEntity, entityData)
-		pool := world.getComponentPoolByTypeIndex(typeIndex)
-		pool.Free(compPoolIdx)
+	// 触发entity开始销毁的事件，此时entity的所有组件都还可以读取
+	world.Events.EntityDestroying.Invoke(saveEntity)
+
+	// 断开父子关系，保证父entity的子列表中不会残留已销毁的entity
+	detachHierarchy(saveEntity, entityData)
+	// 清理entity的所有关系，目标entity销毁时可能会连带销毁关系的源entity
+	world.cleanupRelations(saveEntity)
+
+	// 逐个删除Entity的所有组件，组件删除事件的回调中可能会再删除组件，所以循环直到组件全部删除。
+	// entity已经被标记为IsDestroying，回调中不能再添加新的组件，所以循环一定会结束
+	for len(entityData.CompIndices) > 0 {
+		for typeIndex := range entityData.CompIndices {
+			removeComponent(world, saveEntity, entityData, getComponentTypeByIndex(typeIndex))
+		}
 	}
 
+	// 触发entity销毁完成的事件，此时entity已经没有任何组件
+	world.Events.EntityDestroyed.Invoke(saveEntity)
 	// 回收EntityData
 	world.freeEntityData(entity.Id)
 }
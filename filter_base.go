@@ -1,7 +1,7 @@
 package ecs
 
 import (
-	"unsafe"
+	"fmt"
 
 	dataPool "github.com/Lei2050/array-pool"
 )
@@ -59,8 +59,8 @@ type filterBase struct {
 	// 过滤器新增/删除entity时的事件监听
 	// 外部可以通过OnAdd/OnRemove方法注册/移除监听
 	eventListen *FilterEventListen
-	// 外部可以通过AddListener/RemoveListener方法注册/移除监听
-	listeners []FilterEventListener
+	// 外部可以通过AddListener方法注册监听，通过返回的Subscription或RemoveListener移除监听
+	listeners callbackList[FilterEventListener]
 	// 过滤器包含的组件类型索引id列表，即过滤器中的entity必定拥有这些组件
 	// 比如，包含了A、B、C三个组件，他们的TypeIndex分别为1、3、7，那么IncludeTypeIndices就是[1, 3, 7]
 	IncludeTypeIndices []int
@@ -90,51 +90,134 @@ func newFilterBase(world *World, ap afterAddEntityProcesser, rp afterRemoveEntit
 	}
 }
 
-// 外部想监听filter中增加entity事件，可用此方法注册
-func (f *filterBase) AddListener(listener FilterEventListener) {
-	f.listeners = append(f.listeners, listener)
+// 外部想监听filter中增加/移除entity事件，可用此方法注册，
+// 返回的Subscription用于移除监听
+func (f *filterBase) AddListener(listener FilterEventListener) Subscription {
+	return f.listeners.add(listener)
 }
 
-// 外部想监听filter中移除entity事件，可用此方法注册
+// AddListenerWithPriority 按优先级注册监听，priority越小越先被通知，相同优先级的按注册顺序通知。
+// AddListener、OnAdd、OnRemove的优先级为PriorityDefault；
+// GroupFilter等内部索引使用PriorityInternal，保证用户的监听被调用时索引已经更新完毕。
+func (f *filterBase) AddListenerWithPriority(listener FilterEventListener, priority int) Subscription {
+	return f.listeners.addWithPriority(listener, priority)
+}
+
+// 移除通过AddListener注册的监听
 func (f *filterBase) RemoveListener(listener FilterEventListener) {
-	for i, l := range f.listeners {
-		if l == listener {
-			f.listeners = append(f.listeners[:i], f.listeners[i+1:]...)
-			break
-		}
-	}
+	f.listeners.removeFunc(func(l FilterEventListener) bool {
+		return l == listener
+	})
 }
 
 // 通知事件监听：有entity被添加到filter中
 func (f *filterBase) notifyAdd(entity Entity) {
-	for _, listener := range f.listeners {
+	f.listeners.foreach(func(listener FilterEventListener) {
 		listener.OnEntityAdded(entity)
-	}
+	})
 }
 
 // 通知事件监听：有entity从filter中被移除
 func (f *filterBase) notifyRemove(entity Entity) {
-	for _, listener := range f.listeners {
+	f.listeners.foreach(func(listener FilterEventListener) {
 		listener.OnEntityRemoved(entity)
-	}
+	})
 }
 
-// 外部想单独监听filter中增加entity事件，可用此方法注册
-func (f *filterBase) OnAdd(cb func(entity Entity)) {
+// 外部想单独监听filter中增加entity事件，可用此方法注册，
+// 返回的Subscription用于移除监听
+func (f *filterBase) OnAdd(cb func(entity Entity)) Subscription {
 	if f.eventListen == nil {
 		f.eventListen = newFilterEventListener()
 		f.AddListener(f.eventListen)
 	}
-	f.eventListen.EntityAdded.AddCallback(cb)
+	return f.eventListen.EntityAdded.AddCallback(cb)
 }
 
-// 外部想单独监听filter中移除entity事件，可用此方法注册
-func (f *filterBase) OnRemove(cb func(entity Entity)) {
+// 外部想单独监听filter中移除entity事件，可用此方法注册，
+// 返回的Subscription用于移除监听
+func (f *filterBase) OnRemove(cb func(entity Entity)) Subscription {
 	if f.eventListen == nil {
 		f.eventListen = newFilterEventListener()
 		f.AddListener(f.eventListen)
 	}
-	f.eventListen.EntityRemoved.AddCallback(cb)
+	return f.eventListen.EntityRemoved.AddCallback(cb)
+}
+
+// 将entities中下标为idx的EntityId转换为Entity
+func (f *filterBase) entityAt(idx int) Entity {
+	entityId := f.entities.Get(idx)
+	return Entity{
+		Id:       entityId.Id,
+		Gen:      entityId.Gen,
+		WorldPtr: f.world,
+	}
+}
+
+// Len 返回过滤器中entity的数量
+func (f *filterBase) Len() int {
+	return f.entities.Count()
+}
+
+// Count 返回过滤器中entity的数量，与Len相同
+func (f *filterBase) Count() int {
+	return f.entities.Count()
+}
+
+// EntityAt 返回过滤器中下标为idx的entity，idx的范围是[0, Len())，越界会panic。
+// 注意，entity的下标会随着过滤器中entity的增删而变化（删除时会将末尾的entity移到被删除的位置），
+// 所以不要在遍历期间增删entity，也不要长期持有下标。
+func (f *filterBase) EntityAt(idx int) Entity {
+	return f.entityAt(f.checkIndex(idx))
+}
+
+// 检查下标是否越界，越界则panic，否则原样返回
+func (f *filterBase) checkIndex(idx int) int {
+	if idx < 0 || idx >= f.entities.Count() {
+		panic(fmt.Sprintf("filter index out of range [%d] with length %d", idx, f.entities.Count()))
+	}
+	return idx
+}
+
+// IndexOf 返回entity在过滤器中的下标，entity不在过滤器中时返回false，下标的有效期参考EntityAt
+func (f *filterBase) IndexOf(entity Entity) (int, bool) {
+	if entity.World() != f.world {
+		return -1, false
+	}
+	idx, ok := f.entitiesMap[entity.GetId()]
+	if !ok {
+		return -1, false
+	}
+	return idx, true
+}
+
+// Snapshot 返回过滤器中所有entity的拷贝，之后过滤器的变化不会影响返回的切片
+func (f *filterBase) Snapshot() []Entity {
+	count := f.entities.Count()
+	entities := make([]Entity, count)
+	for i := range count {
+		entities[i] = f.entityAt(i)
+	}
+	return entities
+}
+
+// First 返回过滤器中的第一个entity，过滤器为空时返回false。
+// 注意，过滤器中entity的顺序会随着entity的增删而变化，这里的“第一个”并不固定，
+// 通常用于过滤器中只有一个entity的场景。
+func (f *filterBase) First() (Entity, bool) {
+	if f.entities.Count() == 0 {
+		return Entity{}, false
+	}
+	return f.entityAt(0), true
+}
+
+// Contains 判断entity是否在过滤器中
+func (f *filterBase) Contains(entity Entity) bool {
+	if entity.World() != f.world {
+		return false
+	}
+	_, ok := f.entitiesMap[entity.GetId()]
+	return ok
 }
 
 func initMask1[T any](typeIndices *[]int, mask *uint64) {
@@ -238,6 +321,11 @@ func (f *filterBase) addEntity(entity Entity) {
 	if !entityData.isCurrentEntityData(entity) {
 		return
 	}
+	// 正在销毁的entity不能再加入过滤器，
+	// 比如销毁时删除了某个被排斥的组件，entity可能会满足排斥过滤器的条件
+	if entityData.IsDestroying {
+		return
+	}
 
 	entityId := entity.GetId()
 	if _, ok := f.entitiesMap[entityId]; ok {
@@ -277,6 +365,10 @@ func (f *filterBase) removeEntity(entity Entity) {
 	f.notifyRemove(entity)
 }
 
+func (f *filterBase) getWorld() *World {
+	return f.world
+}
+
 func (f *filterBase) getIncludeTypeIndices() []int {
 	return f.IncludeTypeIndices
 }
@@ -285,6 +377,7 @@ func (f *filterBase) getExcludeTypeIndices() []int {
 }
 
 type IFilter interface {
+	getWorld() *World
 	getIncludeTypeIndices() []int
 	getExcludeTypeIndices() []int
 	isCompatibleAfterAddIncluded(entityData *EntityData) bool
@@ -294,7 +387,9 @@ type IFilter interface {
 	addEntity(entity Entity)
 	removeEntity(entity Entity)
 
-	AddListener(listener FilterEventListener)
+	Contains(entity Entity) bool
+	AddListener(listener FilterEventListener) Subscription
+	AddListenerWithPriority(listener FilterEventListener, priority int) Subscription
 	RemoveListener(listener FilterEventListener)
 }
 
@@ -344,12 +439,20 @@ func (f *filterBase1[Include1]) Foreach(callback func(entity Entity, comp Includ
 		entity := Entity{
 			Id:       entityId.Id,
 			Gen:      entityId.Gen,
-			WorldPtr: uintptr(unsafe.Pointer(f.world)),
+			WorldPtr: f.world,
 		}
 		callback(entity, *f.include1.GetItem(i))
 	}
 }
 
+// Get1 返回过滤器中下标为idx的entity的Include1组件，下标的含义参考EntityAt。
+// 注意，不要持有返回的组件指针，参考TryGet的注释；
+// 返回的指针用于只读，若要修改组件数据，请对EntityAt(idx)使用GetForWrite，或者修改后调用MarkDirty，
+// 否则groupFilter等索引不会更新。
+func (f *filterBase1[Include1]) Get1(idx int) *Include1 {
+	return f.include1.GetItem(f.checkIndex(idx))
+}
+
 // 与filterBase1类似，filterBase2要求Entity必须同时包含Include1和Include2组件，
 // 即实现过滤同时包含Include1和Include2组件的Entity。
 type filterBase2[Include1, Include2 any] struct {
@@ -391,12 +494,22 @@ func (f *filterBase2[Include1, Include2]) Foreach(callback func(entity Entity, c
 		entity := Entity{
 			Id:       entityId.Id,
 			Gen:      entityId.Gen,
-			WorldPtr: uintptr(unsafe.Pointer(f.world)),
+			WorldPtr: f.world,
 		}
 		callback(entity, *f.include1.GetItem(i), *f.include2.GetItem(i))
 	}
 }
 
+// Get1 返回过滤器中下标为idx的entity的Include1组件，参考filterBase1.Get1
+func (f *filterBase2[Include1, Include2]) Get1(idx int) *Include1 {
+	return f.include1.GetItem(f.checkIndex(idx))
+}
+
+// Get2 返回过滤器中下标为idx的entity的Include2组件，参考filterBase1.Get1
+func (f *filterBase2[Include1, Include2]) Get2(idx int) *Include2 {
+	return f.include2.GetItem(f.checkIndex(idx))
+}
+
 // filterBase3实现过滤同时包含Include1、Include2、Include3的Entity。
 type filterBase3[Include1, Include2, Include3 any] struct {
 	*filterBase
@@ -443,12 +556,24 @@ func (f *filterBase3[Include1, Include2, Include3]) Foreach(callback func(entity
 		entity := Entity{
 			Id:       entityId.Id,
 			Gen:      entityId.Gen,
-			WorldPtr: uintptr(unsafe.Pointer(f.world)),
+			WorldPtr: f.world,
 		}
 		callback(entity, *f.include1.GetItem(i), *f.include2.GetItem(i), *f.include3.GetItem(i))
 	}
 }
 
+func (f *filterBase3[Include1, Include2, Include3]) Get1(idx int) *Include1 {
+	return f.include1.GetItem(f.checkIndex(idx))
+}
+
+func (f *filterBase3[Include1, Include2, Include3]) Get2(idx int) *Include2 {
+	return f.include2.GetItem(f.checkIndex(idx))
+}
+
+func (f *filterBase3[Include1, Include2, Include3]) Get3(idx int) *Include3 {
+	return f.include3.GetItem(f.checkIndex(idx))
+}
+
 // filterBase3实现过滤同时包含Include1、Include2、Include3、Include4的Entity。
 type filterBase4[Include1, Include2, Include3, Include4 any] struct {
 	*filterBase
@@ -501,8 +626,24 @@ func (f *filterBase4[Include1, Include2, Include3, Include4]) Foreach(callback f
 		entity := Entity{
 			Id:       entityId.Id,
 			Gen:      entityId.Gen,
-			WorldPtr: uintptr(unsafe.Pointer(f.world)),
+			WorldPtr: f.world,
 		}
 		callback(entity, *f.include1.GetItem(i), *f.include2.GetItem(i), *f.include3.GetItem(i), *f.include4.GetItem(i))
 	}
 }
+
+func (f *filterBase4[Include1, Include2, Include3, Include4]) Get1(idx int) *Include1 {
+	return f.include1.GetItem(f.checkIndex(idx))
+}
+
+func (f *filterBase4[Include1, Include2, Include3, Include4]) Get2(idx int) *Include2 {
+	return f.include2.GetItem(f.checkIndex(idx))
+}
+
+func (f *filterBase4[Include1, Include2, Include3, Include4]) Get3(idx int) *Include3 {
+	return f.include3.GetItem(f.checkIndex(idx))
+}
+
+func (f *filterBase4[Include1, Include2, Include3, Include4]) Get4(idx int) *Include4 {
+	return f.include4.GetItem(f.checkIndex(idx))
+}
@@ -0,0 +1,189 @@
+package ecs
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func TestIndexListenersRunBeforeUserCallbacks(t *testing.T) {
+	world := NewWorld()
+	filter := RegisterFilter(world, NewFilter1[spatialTestPos](world))
+	// 返回包含entity的索引，索引在用户回调之后才创建
+	var indexedBy func(entity Entity) []string
+	// 先于索引注册的用户回调，也应该在索引更新之后被调用
+	var added, removed []string
+	filter.OnAdd(func(entity Entity) {
+		added = append(added, indexedBy(entity)...)
+	})
+	filter.OnRemove(func(entity Entity) {
+		removed = append(removed, indexedBy(entity)...)
+	})
+	gf := RegisterGroupFilter(world, NewGroupFilter[spatialTestPos](world))
+	si := NewSpatialIndex[spatialTestPos, spatialTestPosMapper](world, 10)
+	view := SortBy(filter, func(a, b *spatialTestPos) int { return cmp.Compare(a.X, b.X) })
+	indexedBy = func(entity Entity) []string {
+		var result []string
+		if found, ok := gf.FindOne(spatialTestPos{X: 1, Y: 2}); ok && found == entity {
+			result = append(result, "GroupFilter")
+		}
+		if _, _, ok := si.PositionOf(entity); ok {
+			result = append(result, "SpatialIndex")
+		}
+		if slices.Contains(view.Entities(), entity) {
+			result = append(result, "SortedView")
+		}
+		return result
+	}
+
+	entity := world.NewEntity()
+	Replace(entity, spatialTestPos{X: 1, Y: 2})
+	if want := []string{"GroupFilter", "SpatialIndex", "SortedView"}; !slices.Equal(added, want) {
+		t.Errorf("indexes containing the entity in OnAdd = %v, want %v", added, want)
+	}
+	Del[spatialTestPos](entity)
+	if len(removed) != 0 {
+		t.Errorf("indexes containing the entity in OnRemove = %v, want none", removed)
+	}
+}
+
+// filterEventRecorder 记录过滤器的增删事件
+type filterEventRecorder struct {
+	added, removed []Entity
+}
+
+func (r *filterEventRecorder) OnEntityAdded(entity Entity) {
+	r.added = append(r.added, entity)
+}
+
+func (r *filterEventRecorder) OnEntityRemoved(entity Entity) {
+	r.removed = append(r.removed, entity)
+}
+
+func recordFilterEvents(filter IFilter) *filterEventRecorder {
+	r := &filterEventRecorder{}
+	filter.AddListener(r)
+	return r
+}
+
+func TestFilterCountFirstContains(t *testing.T) {
+	world := NewWorld()
+	filter := RegisterFilter(world, NewFilter1Exclude[worldTestHp, worldTestTag](world))
+	if _, ok := filter.First(); ok {
+		t.Errorf("First of an empty filter returned true")
+	}
+	a, b, tagged := world.NewEntity(), world.NewEntity(), world.NewEntity()
+	Replace(a, worldTestHp{})
+	Replace(b, worldTestHp{})
+	AddComponents(tagged, worldTestHp{}, worldTestTag{})
+	if got := filter.Count(); got != 2 {
+		t.Errorf("Count = %d, want 2", got)
+	}
+	if first, ok := filter.First(); !ok || first != a && first != b {
+		t.Errorf("First = %v, %v, want a or b, true", first, ok)
+	}
+	// 其他world中相同Id的entity不在过滤器中
+	other := NewWorld()
+	otherEntity := other.NewEntity()
+	Replace(otherEntity, worldTestHp{})
+	for _, tt := range []struct {
+		name   string
+		entity Entity
+		want   bool
+	}{
+		{name: "included", entity: a, want: true},
+		{name: "excluded", entity: tagged, want: false},
+		{name: "other world", entity: otherEntity, want: false},
+	} {
+		if got := filter.Contains(tt.entity); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	a.Destroy()
+	if filter.Contains(a) {
+		t.Errorf("Contains(destroyed) = true, want false")
+	}
+	if first, ok := filter.First(); !ok || first != b {
+		t.Errorf("First after destroy = %v, %v, want b, true", first, ok)
+	}
+	if got := filter.Count(); got != 1 {
+		t.Errorf("Count after destroy = %d, want 1", got)
+	}
+}
+
+func TestFilterIndexAccess(t *testing.T) {
+	_, filter, entities := newWorldTestWorld(4)
+	if got := filter.Len(); got != 4 {
+		t.Fatalf("Len = %d, want 4", got)
+	}
+	for _, entity := range entities {
+		idx, ok := filter.IndexOf(entity)
+		if !ok {
+			t.Fatalf("IndexOf(%v) not found", entity)
+		}
+		if got := filter.EntityAt(idx); got != entity {
+			t.Errorf("EntityAt(%d) = %v, want %v", idx, got, entity)
+		}
+		// Get1返回的是组件池中的指针，而不是拷贝
+		if got, want := filter.Get1(idx), Get[worldTestHp](entity); got != want {
+			t.Errorf("Get1(%d) = %p, want %p", idx, got, want)
+		}
+	}
+	for _, idx := range []int{-1, 4} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("EntityAt(%d) did not panic", idx)
+				}
+			}()
+			filter.EntityAt(idx)
+		}()
+	}
+
+	// 遍历快照的同时销毁entity，快照不受影响
+	snapshot := filter.Snapshot()
+	want := slices.Clone(snapshot)
+	for _, entity := range snapshot {
+		entity.Destroy()
+	}
+	if !slices.Equal(snapshot, want) {
+		t.Errorf("snapshot changed to %v, want %v", snapshot, want)
+	}
+	for _, entity := range entities {
+		if !slices.Contains(snapshot, entity) {
+			t.Errorf("snapshot does not contain %v", entity)
+		}
+		if _, ok := filter.IndexOf(entity); ok {
+			t.Errorf("IndexOf(destroyed %v) found", entity)
+		}
+	}
+	if got := filter.Len(); got != 0 {
+		t.Errorf("Len after destroy = %d, want 0", got)
+	}
+	// 其他world的entity
+	other := NewWorld()
+	if _, ok := filter.IndexOf(other.NewEntity()); ok {
+		t.Errorf("IndexOf(entity of another world) found")
+	}
+}
+
+func TestFilterGetReturnsPoolPointers(t *testing.T) {
+	world := NewWorld()
+	filter := RegisterFilter(world, NewFilter4[worldTestHp, worldTestItems, spatialTestPos, sortedTestZ](world))
+	entity := world.NewEntity()
+	AddComponents(entity, worldTestHp{Val: 1}, worldTestItems{}, spatialTestPos{X: 2}, sortedTestZ{Z: 3})
+	idx, ok := filter.IndexOf(entity)
+	if !ok {
+		t.Fatalf("entity is not in the filter")
+	}
+	if filter.Get1(idx) != Get[worldTestHp](entity) ||
+		filter.Get2(idx) != Get[worldTestItems](entity) ||
+		filter.Get3(idx) != Get[spatialTestPos](entity) ||
+		filter.Get4(idx) != Get[sortedTestZ](entity) {
+		t.Errorf("Get1..Get4 do not point to the components of the entity")
+	}
+	if filter.Get1(idx).Val != 1 || filter.Get3(idx).X != 2 || filter.Get4(idx).Z != 3 {
+		t.Errorf("Get1..Get4 return wrong component data")
+	}
+}
@@ -1,5 +1,10 @@
 package ecs
 
+import (
+	"fmt"
+	"slices"
+)
+
 // GroupFilter[KeyComp comparable] 提供给用户使用的过滤器，
 // 用于筛选持有KeyComp组件的Entity，同时支持通过KeyComp快速获取Entity。
 // 相当于Filter1[KeyComp].GroupBy[KeyComp]，
@@ -115,3 +120,45 @@ func NewGroupFilter3WithKeyMapper[KeyComp1, KeyComp2, KeyComp3 any, Key1, Key2,
 	registerGroupKeyEventByTypeAndHandler[KeyComp3](world, handler)
 	return gf
 }
+
+// GroupFilterFunc[Key comparable] 可以建立在任意已注册的IFilter之上的分组过滤器，
+// 比如FilterNExcludeM，key由用户提供的函数keyOf从Entity中提取，不受key组件数量的限制。
+// 相当于filter.GroupBy[keyOf(entity)]。
+// 同一个Key类型可能有多个GroupFilterFunc，可以使用RegisterGroupFilterNamed/GetGroupFilterNamed按名称注册/获取。
+type GroupFilterFunc[Key comparable] struct {
+	*groupFilterBase[Key, funcKeyMaker[Key]]
+}
+
+// NewGroupFilterFunc 创建一个建立在filter之上的GroupFilterFunc，filter必须已经注册到world中。
+// keyOf用于从Entity中提取key，它只能读取Entity的组件（Get），不能修改world；
+// deps为keyOf所读取的组件类型（GetComponentType[T]()），这些组件被替换/写入时，Entity会按新的key重新分组，
+// deps中的组件必须是filter所包含的组件，否则触发 panic。
+// 注意，keyOf读取的组件都必须列在deps中：不在deps中的组件被修改时，Entity不会重新分组，
+// 之后也无法按旧的key正确地移除，并且这种错误无法被检测出来。
+func NewGroupFilterFunc[Key comparable](world *World, filter IFilter, keyOf func(entity Entity) Key, deps ...*ComponentType) *GroupFilterFunc[Key] {
+	checkFilterDeps(world, filter, deps)
+	gf := &GroupFilterFunc[Key]{
+		newGroupFilterBase[Key, funcKeyMaker[Key]](filter),
+	}
+	gf.keyMaker = funcKeyMaker[Key]{keyOf: keyOf}
+	//deps中的组件是groupKey，监听其增删
+	for _, dep := range deps {
+		registerGroupKeyEventByTypeIndex(world, dep.TypeIndex, gf, filter)
+	}
+	return gf
+}
+
+// 检查建立在filter之上的索引的参数：filter必须已经注册到world中，deps必须是filter所包含的组件
+func checkFilterDeps(world *World, filter IFilter, deps []*ComponentType) {
+	if filter.getWorld() != world {
+		panic("filter does not belong to this world")
+	}
+	if !world.isFilterRegistered(filter) {
+		panic("filter is not registered")
+	}
+	for _, dep := range deps {
+		if !slices.Contains(filter.getIncludeTypeIndices(), dep.TypeIndex) {
+			panic(fmt.Sprintf("the filter does not include the dependent component:%s", dep.Type.Name()))
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package ecs
 
+import (
+	"iter"
+	"maps"
+)
+
 type iEntitySet interface {
 	Add(e Entity)
 	Remove(e Entity)
@@ -17,17 +22,22 @@ type IGroupFilter interface {
 //
 //	通过注册FilterEventListener接口）。
 type groupFilterBase[Key comparable, KeyMaker groupKeyMaker[Key]] struct {
+	groupKeySyncer
 	keyMaker KeyMaker
 	entities map[Key]Set[Entity] //set大部分情况可能只有一个元素，可以用数组链表优化
+	// 每个Entity加入时的key，用于准确地移除Entity
+	keyOf map[Entity]Key
 }
 
 // 实例化一个groupFilterBase，需要传入一个所以依赖的IFilter。
 func newGroupFilterBase[Key comparable, KeyMaker groupKeyMaker[Key]](filter IFilter) *groupFilterBase[Key, KeyMaker] {
 	gf := &groupFilterBase[Key, KeyMaker]{
-		entities: make(map[Key]Set[Entity]),
+		groupKeySyncer: groupKeySyncer{world: filter.getWorld()},
+		entities:       make(map[Key]Set[Entity]),
+		keyOf:          make(map[Entity]Key),
 	}
-	//监听filter的Entity增删事件
-	filter.AddListener(gf)
+	//监听filter的Entity增删事件，使用内部优先级，保证用户的监听被调用时分组已经更新
+	filter.AddListenerWithPriority(gf, PriorityInternal)
 	return gf
 }
 
@@ -37,22 +47,37 @@ func (gf *groupFilterBase[Key, KeyMaker]) iamGroupFilter() {}
 // 实现当所依赖的filter中的Entity发生变动时，groupFilterBase会自动更新自己的Entity集合
 func (gf *groupFilterBase[Key, KeyMaker]) OnEntityAdded(entity Entity) {
 	key := gf.keyMaker.makeKey(entity)
+	if oldKey, ok := gf.keyOf[entity]; ok {
+		if oldKey == key {
+			return
+		}
+		// 已经在其他分组中，先从旧的分组中移除
+		gf.OnEntityRemoved(entity)
+	}
 	set, ok := gf.entities[key]
 	if !ok {
 		set = make(Set[Entity])
-		gf.entities[gf.keyMaker.makeKey(entity)] = set
+		gf.entities[key] = set
 	}
 	set.Add(entity)
+	gf.keyOf[entity] = key
 }
 
 // 实现FilterEventListener接口
-// 实现当所依赖的filter中的Entity发生变动时，groupFilterBase会自动更新自己的Entity集合
+// 实现当所依赖的filter中的Entity发生变动时，groupFilterBase会自动更新自己的Entity集合。
+// 使用加入时记录的key移除，而不是根据当前的组件数据重新计算，组件数据此时可能已经被修改或删除。
 func (gf *groupFilterBase[Key, KeyMaker]) OnEntityRemoved(entity Entity) {
-	set, ok := gf.entities[gf.keyMaker.makeKey(entity)]
+	key, ok := gf.keyOf[entity]
 	if !ok {
 		return
 	}
+	delete(gf.keyOf, entity)
+	set := gf.entities[key]
 	delete(set, entity)
+	// 回收空的集合，避免出现过的key越来越多
+	if len(set) == 0 {
+		delete(gf.entities, key)
+	}
 }
 
 // 实现iEntitySet接口
@@ -67,6 +92,7 @@ func (gf *groupFilterBase[Key, KeyMaker]) Remove(entity Entity) {
 
 // 根据key找到任意一个Entity
 func (gf *groupFilterBase[Key, KeyMaker]) FindOne(key Key) (Entity, bool) {
+	gf.syncGroupKeys()
 	set, ok := gf.entities[key]
 	if ok {
 		for e := range set {
@@ -78,6 +104,7 @@ func (gf *groupFilterBase[Key, KeyMaker]) FindOne(key Key) (Entity, bool) {
 
 // 遍历keyMaker(entity)==key的所有Entity
 func (gf *groupFilterBase[Key, KeyMaker]) Foreach(key Key, f func(Entity)) {
+	gf.syncGroupKeys()
 	set, ok := gf.entities[key]
 	if ok {
 		for e := range set {
@@ -85,3 +112,42 @@ func (gf *groupFilterBase[Key, KeyMaker]) Foreach(key Key, f func(Entity)) {
 		}
 	}
 }
+
+// KeyOf 返回Entity所在分组的key，Entity不在分组中时返回false
+func (gf *groupFilterBase[Key, KeyMaker]) KeyOf(entity Entity) (Key, bool) {
+	gf.syncGroupKeys()
+	key, ok := gf.keyOf[entity]
+	return key, ok
+}
+
+// Keys 返回所有的key（至少有一个Entity），顺序是不确定的
+func (gf *groupFilterBase[Key, KeyMaker]) Keys() []Key {
+	gf.syncGroupKeys()
+	keys := make([]Key, 0, len(gf.entities))
+	for key := range gf.entities {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Count 返回key对应的Entity数量
+func (gf *groupFilterBase[Key, KeyMaker]) Count(key Key) int {
+	gf.syncGroupKeys()
+	return len(gf.entities[key])
+}
+
+// Len 返回分组（不同的key）的数量
+func (gf *groupFilterBase[Key, KeyMaker]) Len() int {
+	gf.syncGroupKeys()
+	return len(gf.entities)
+}
+
+// ForeachGroup 遍历所有分组，entities为该分组中的Entity，比如统计每个省份的玩家数量。
+// 分组之间的顺序是不确定的。
+// 注意，entities只在f执行期间有效，遍历期间不要修改分组的key。
+func (gf *groupFilterBase[Key, KeyMaker]) ForeachGroup(f func(key Key, entities iter.Seq[Entity])) {
+	gf.syncGroupKeys()
+	for key, set := range gf.entities {
+		f(key, maps.Keys(set))
+	}
+}
@@ -0,0 +1,122 @@
+package ecs
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// 返回groupFilter中每个key对应的entity数量
+func groupSizes[Key comparable, KeyMaker groupKeyMaker[Key]](gf *groupFilterBase[Key, KeyMaker]) map[Key]int {
+	sizes := make(map[Key]int)
+	gf.ForeachGroup(func(key Key, entities iter.Seq[Entity]) {
+		for range entities {
+			sizes[key]++
+		}
+	})
+	return sizes
+}
+
+func TestGroupFilterGroups(t *testing.T) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[groupTestKey](world))
+	gf := NewGroupFilter[groupTestKey](world)
+	entities := make([]Entity, 5)
+	for i := range entities {
+		entities[i] = world.NewEntity()
+		// key分别为0, 1, 0, 1, 2
+		Replace(entities[i], groupTestKey{V: i % 2})
+	}
+	Replace(entities[4], groupTestKey{V: 2})
+
+	check := func(step string, want map[groupTestKey]int) {
+		t.Helper()
+		keys := gf.Keys()
+		slices.SortFunc(keys, func(a, b groupTestKey) int { return a.V - b.V })
+		wantKeys := slices.SortedFunc(maps.Keys(want), func(a, b groupTestKey) int { return a.V - b.V })
+		if !slices.Equal(keys, wantKeys) {
+			t.Errorf("%s: Keys = %v, want %v", step, keys, wantKeys)
+		}
+		if got := gf.Len(); got != len(want) {
+			t.Errorf("%s: Len = %d, want %d", step, got, len(want))
+		}
+		for key, count := range want {
+			if got := gf.Count(key); got != count {
+				t.Errorf("%s: Count(%v) = %d, want %d", step, key, got, count)
+			}
+		}
+		if got := groupSizes(gf.groupFilterBase); !maps.Equal(got, want) {
+			t.Errorf("%s: ForeachGroup = %v, want %v", step, got, want)
+		}
+		// 空的分组被回收
+		if len(gf.entities) != len(want) {
+			t.Errorf("%s: %d buckets, want %d", step, len(gf.entities), len(want))
+		}
+	}
+	check("initial", map[groupTestKey]int{{V: 0}: 2, {V: 1}: 2, {V: 2}: 1})
+	if got := gf.Count(groupTestKey{V: 3}); got != 0 {
+		t.Errorf("Count of an unknown key = %d, want 0", got)
+	}
+
+	// 最后一个entity换到其他分组
+	GetForWrite[groupTestKey](entities[4]).V = 1
+	check("rekey", map[groupTestKey]int{{V: 0}: 2, {V: 1}: 3})
+
+	// 分组中的entity全部销毁或删除key组件
+	entities[0].Destroy()
+	Del[groupTestKey](entities[2])
+	check("remove", map[groupTestKey]int{{V: 1}: 3})
+
+	// 分组被回收后可以再次创建
+	Replace(entities[2], groupTestKey{V: 0})
+	check("re-add", map[groupTestKey]int{{V: 0}: 1, {V: 1}: 3})
+}
+
+func TestGroupFilterRemoveByRecordedKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(entity Entity)
+	}{
+		{name: "Destroy", remove: func(entity Entity) { entity.Destroy() }},
+		{name: "Del", remove: func(entity Entity) { Del[groupTestKey](entity) }},
+		{name: "DestroyDeferred", remove: func(entity Entity) {
+			entity.DestroyDeferred()
+			entity.World().FlushDestroyed()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			RegisterFilter(world, NewFilter1[groupTestKey](world))
+			gf := NewGroupFilter[groupTestKey](world)
+			entity, other := world.NewEntity(), world.NewEntity()
+			Replace(entity, groupTestKey{V: 1})
+			Replace(other, groupTestKey{V: 1})
+			if key, ok := gf.KeyOf(entity); !ok || key.V != 1 {
+				t.Fatalf("KeyOf = %v, %v, want {1}, true", key, ok)
+			}
+
+			// 绕过写入接口修改key，没有key事件，分组仍然使用加入时的key
+			Get[groupTestKey](entity).V = 5
+			if key, ok := gf.KeyOf(entity); !ok || key.V != 1 {
+				t.Errorf("KeyOf after a silent write = %v, %v, want {1}, true", key, ok)
+			}
+
+			// 移除时使用记录的key，不会残留在旧的分组中
+			tt.remove(entity)
+			if _, ok := gf.KeyOf(entity); ok {
+				t.Errorf("KeyOf of a removed entity found")
+			}
+			if got := gf.Count(groupTestKey{V: 1}); got != 1 {
+				t.Errorf("Count({1}) = %d, want 1", got)
+			}
+			if got := gf.Len(); got != 1 {
+				t.Errorf("Len = %d, want 1", got)
+			}
+			if found, ok := gf.FindOne(groupTestKey{V: 1}); !ok || found != other {
+				t.Errorf("FindOne({1}) = %v, %v, want other, true", found, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package ecs
+
+import "testing"
+
+type groupTestKey struct {
+	V int
+}
+
+type groupTestId struct {
+	V int
+}
+
+func init() {
+	RegisterComponentType[groupTestKey](16)
+	RegisterComponentType[groupTestId](16)
+}
+
+func TestGroupFilterReindexOnWritePaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(entity Entity)
+	}{
+		{name: "GetForWrite", write: func(entity Entity) { GetForWrite[groupTestKey](entity).V = 2 }},
+		{name: "GetMayForWrite", write: func(entity Entity) { GetMayForWrite[groupTestKey](entity).V = 2 }},
+		{name: "TryGetMayForWrite", write: func(entity Entity) {
+			k, _ := TryGetMayForWrite[groupTestKey](entity)
+			k.V = 2
+		}},
+		{name: "EnsureMayForWrite", write: func(entity Entity) { EnsureMayForWrite[groupTestKey](entity).V = 2 }},
+		{name: "MarkDirty after write", write: func(entity Entity) {
+			Get[groupTestKey](entity).V = 2
+			MarkDirty[groupTestKey](entity)
+		}},
+		{name: "SingleMut", write: func(entity Entity) {
+			_, k := SingleMut[groupTestKey](entity.World())
+			k.V = 2
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			RegisterFilter(world, NewFilter1[groupTestKey](world))
+			gf := NewGroupFilter[groupTestKey](world)
+			entity := world.NewEntity()
+			Replace(entity, groupTestKey{V: 1})
+			tt.write(entity)
+			if _, ok := gf.FindOne(groupTestKey{V: 1}); ok {
+				t.Errorf("entity still found by the old key")
+			}
+			if got, ok := gf.FindOne(groupTestKey{V: 2}); !ok || got != entity {
+				t.Errorf("FindOne(new key) = %v, %v, want %v, true", got, ok, entity)
+			}
+		})
+	}
+}
+
+func TestGroupFilterQuerySyncsOnlyItsKeys(t *testing.T) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[groupTestKey](world))
+	RegisterFilter(world, NewFilter1[groupTestId](world))
+	gf := NewGroupFilter[groupTestKey](world)
+	unique := NewUniqueGroupFilter[groupTestId](world, UniqueConflictPanic)
+	e1 := world.NewEntity()
+	Replace(e1, groupTestKey{V: 1})
+	Replace(e1, groupTestId{V: 1})
+	e2 := world.NewEntity()
+	Replace(e2, groupTestKey{V: 2})
+	Replace(e2, groupTestId{V: 2})
+
+	// 制造一个唯一索引的冲突，查询无关的groupFilter时不应该panic
+	GetForWrite[groupTestId](e2).V = 1
+	GetForWrite[groupTestKey](e2).V = 3
+	if got, ok := gf.FindOne(groupTestKey{V: 3}); !ok || got != e2 {
+		t.Errorf("FindOne = %v, %v, want %v, true", got, ok, e2)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("querying the unique filter should panic on conflict")
+		}
+	}()
+	unique.Get(groupTestId{V: 1})
+}
+
+func TestGroupFilterFuncRekeysThroughDeps(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(entity Entity)
+		// 修改后期望的key
+		want int
+	}{
+		{name: "Replace first dep", write: func(entity Entity) { Replace(entity, groupTestKey{V: 2}) }, want: 203},
+		{name: "Replace second dep", write: func(entity Entity) { Replace(entity, groupTestId{V: 4}) }, want: 104},
+		{name: "GetForWrite", write: func(entity Entity) { GetForWrite[groupTestId](entity).V = 5 }, want: 105},
+		{name: "MarkDirty", write: func(entity Entity) {
+			Get[groupTestKey](entity).V = 6
+			MarkDirty[groupTestKey](entity)
+		}, want: 603},
+		{name: "AddComponents", write: func(entity Entity) {
+			AddComponents(entity, groupTestKey{V: 7}, groupTestId{V: 8})
+		}, want: 708},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			filter := RegisterFilter(world, NewFilter2[groupTestKey, groupTestId](world))
+			gf := NewGroupFilterFunc(world, filter, func(entity Entity) int {
+				return Get[groupTestKey](entity).V*100 + Get[groupTestId](entity).V
+			}, GetComponentType[groupTestKey](), GetComponentType[groupTestId]())
+			entity := world.NewEntity()
+			AddComponents(entity, groupTestKey{V: 1}, groupTestId{V: 3})
+			if found, ok := gf.FindOne(103); !ok || found != entity {
+				t.Fatalf("FindOne(103) = %v, %v, want %v, true", found, ok, entity)
+			}
+			tt.write(entity)
+			if _, ok := gf.FindOne(103); ok {
+				t.Errorf("entity still found by the old key")
+			}
+			if found, ok := gf.FindOne(tt.want); !ok || found != entity {
+				t.Errorf("FindOne(%d) = %v, %v, want %v, true", tt.want, found, ok, entity)
+			}
+		})
+	}
+}
+
+func TestGroupFilterFuncChecksFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		// 返回用于创建GroupFilterFunc的world和filter
+		setup func() (*World, IFilter)
+		deps  []*ComponentType
+	}{
+		{name: "unregistered filter", setup: func() (*World, IFilter) {
+			world := NewWorld()
+			return world, NewFilter1[groupTestKey](world)
+		}},
+		{name: "filter of another world", setup: func() (*World, IFilter) {
+			other := NewWorld()
+			return NewWorld(), RegisterFilter(other, NewFilter1[groupTestKey](other))
+		}},
+		{name: "dep not included by the filter", setup: func() (*World, IFilter) {
+			world := NewWorld()
+			return world, RegisterFilter(world, NewFilter1[groupTestKey](world))
+		}, deps: []*ComponentType{GetComponentType[groupTestId]()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, filter := tt.setup()
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewGroupFilterFunc did not panic")
+				}
+			}()
+			NewGroupFilterFunc(world, filter, func(entity Entity) int { return 0 }, tt.deps...)
+		})
+	}
+}
@@ -24,16 +24,38 @@ type groupKeyEventProxy struct {
 }
 
 func (g *groupKeyEventProxy) onGroupKeyEvent(eventKind groupKeyEventKind, entity Entity) {
+	// 不在filter中的entity也不会在groupFilter中，而且它可能缺少其他的key组件，无法生成key。
+	// 这里判断entity是否在filter中，而不是是否满足filter的条件：
+	// 延迟销毁并且已经从filter中移除（DeferredHideImmediately）的entity仍然满足条件，
+	// 但不能再加入groupFilter，否则FlushDestroyed时不会从filter中移除，也就不会从groupFilter中移除
+	if !g.filter.Contains(entity) {
+		return
+	}
 	switch eventKind {
 	case groupKeyAdd:
-		if g.filter.isCompatibleAfterAddIncluded(entity.getEntityData()) {
-			g.set.Add(entity)
-		}
+		g.set.Add(entity)
 	case groupKeyRemove:
 		g.set.Remove(entity)
 	}
 }
 
+// groupKeySyncer 嵌入到groupFilter等索引中，记录索引所依赖的key组件，
+// 查询索引前只同步这些组件的写入（参考World.SyncGroupKeys），不会触发其他无关索引的更新。
+type groupKeySyncer struct {
+	world *World
+	// 所依赖的key组件的类型索引，注册groupKey事件时记录
+	keyTypes []int
+}
+
+func (s *groupKeySyncer) addGroupKeyType(typeIndex int) {
+	s.keyTypes = append(s.keyTypes, typeIndex)
+}
+
+// 同步所依赖的key组件的写入
+func (s *groupKeySyncer) syncGroupKeys() {
+	s.world.syncGroupKeys(s.keyTypes)
+}
+
 // 键值生成器：从Entity中提取Key
 type groupKeyMaker[Key comparable] interface {
 	makeKey(Entity) Key
@@ -68,6 +90,16 @@ func (d Directly3KeyMaker[KeyComp1, KeyComp2, KeyComp3]) makeKey(entity Entity)
 	return Multi3Key[KeyComp1, KeyComp2, KeyComp3]{*Get[KeyComp1](entity), *Get[KeyComp2](entity), *Get[KeyComp3](entity)}
 }
 
+// 由用户提供的函数从Entity中提取Key的键值生成器
+// 实现groupKeyMaker[Key]接口
+type funcKeyMaker[Key comparable] struct {
+	keyOf func(Entity) Key
+}
+
+func (f funcKeyMaker[Key]) makeKey(entity Entity) Key {
+	return f.keyOf(entity)
+}
+
 // 接口功能：将Source转换为Key
 type IGroupKeyMap[Source any, Key comparable] interface {
 	MapKey(Source) Key
@@ -0,0 +1,141 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ParentComponent 记录entity的父entity。
+// 由SetParent/RemoveParent维护，不要直接Replace/Del该组件，否则父子关系会不一致。
+type ParentComponent struct {
+	Parent Entity
+}
+
+// ChildrenComponent 记录entity的所有子entity。
+// 由SetParent/RemoveParent维护，不要直接修改，最后一个子entity移除时该组件会被自动删除。
+type ChildrenComponent struct {
+	Children []Entity
+}
+
+var (
+	parentComponentType   *ComponentType
+	childrenComponentType *ComponentType
+)
+
+func init() {
+	parentComponentType = RegisterComponentType[ParentComponent](segmentSize)
+	childrenComponentType = RegisterComponentType[ChildrenComponent](segmentSize)
+}
+
+// SetParent 将child挂到parent下，若child已有父entity，会先从原父entity中移除。
+// child和parent必须属于同一个World，且parent不能是child自身或其子孙，否则触发 panic。
+func SetParent(child, parent Entity) {
+	if child.WorldPtr != parent.WorldPtr {
+		panic("child and parent must belong to the same world")
+	}
+	if !child.IsAlive() || !parent.IsAlive() {
+		panic("entity is not alive")
+	}
+	// 沿着parent向上查找，防止出现环
+	for p, ok := parent, true; ok; p, ok = GetParent(p) {
+		if p.Equal(child) {
+			panic(fmt.Sprintf("entity:%+v can not be parent of its ancestor:%+v", parent, child))
+		}
+	}
+
+	if old, ok := GetParent(child); ok {
+		if old.Equal(parent) {
+			return
+		}
+		removeChild(old, child)
+	}
+	Replace(child, ParentComponent{Parent: parent})
+	children := EnsureMayForWrite[ChildrenComponent](parent)
+	children.Children = append(children.Children, child)
+}
+
+// RemoveParent 将child从其父entity中移除，使其成为根entity。
+// child没有父entity时返回 false。
+func RemoveParent(child Entity) bool {
+	parent, ok := GetParent(child)
+	if !ok {
+		return false
+	}
+	removeChild(parent, child)
+	Del[ParentComponent](child)
+	return true
+}
+
+// GetParent 获取child的父entity，child没有父entity时返回 false。
+func GetParent(child Entity) (Entity, bool) {
+	pc, ok := TryGet[ParentComponent](child)
+	if !ok {
+		return Entity{}, false
+	}
+	return pc.Parent, true
+}
+
+// ChildCount 返回parent的直接子entity数量。
+func ChildCount(parent Entity) int {
+	children, ok := TryGet[ChildrenComponent](parent)
+	if !ok {
+		return 0
+	}
+	return len(children.Children)
+}
+
+// ForeachChild 遍历parent的所有直接子entity。
+// 遍历的是子列表的拷贝，所以回调中可以修改父子关系或者销毁子entity。
+func ForeachChild(parent Entity, f func(child Entity)) {
+	children, ok := TryGet[ChildrenComponent](parent)
+	if !ok {
+		return
+	}
+	for _, child := range slices.Clone(children.Children) {
+		f(child)
+	}
+}
+
+// ForeachDescendant 以深度优先（先序）的方式遍历root的所有子孙entity，不包括root自身。
+// f返回 false 时跳过该entity的子树。
+// 注意，遍历期间不要修改父子关系，也不要销毁entity。
+func ForeachDescendant(root Entity, f func(entity Entity) bool) {
+	children, ok := TryGet[ChildrenComponent](root)
+	if !ok {
+		return
+	}
+	for _, child := range children.Children {
+		if f(child) {
+			ForeachDescendant(child, f)
+		}
+	}
+}
+
+// 从parent的子列表中移除child，子列表为空时删除parent的ChildrenComponent
+func removeChild(parent, child Entity) {
+	children, ok := TryGetMayForWrite[ChildrenComponent](parent)
+	if !ok {
+		return
+	}
+	children.Children = slices.DeleteFunc(children.Children, func(e Entity) bool {
+		return e.Equal(child)
+	})
+	if len(children.Children) == 0 && !parent.getEntityData().IsDestroying {
+		Del[ChildrenComponent](parent)
+	}
+}
+
+// detachHierarchy 在entity销毁前断开其父子关系：
+// 从父entity的子列表中移除自己，并使所有子entity成为根entity。
+func detachHierarchy(entity Entity, entityData *EntityData) {
+	if _, ok := entityData.CompIndices[parentComponentType.TypeIndex]; ok {
+		if parent, ok := GetParent(entity); ok {
+			removeChild(parent, entity)
+		}
+	}
+	if _, ok := entityData.CompIndices[childrenComponentType.TypeIndex]; ok {
+		for _, child := range slices.Clone(Get[ChildrenComponent](entity).Children) {
+			Del[ParentComponent](child)
+		}
+	}
+}
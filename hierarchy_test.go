@@ -0,0 +1,213 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+// 按ForeachChild的顺序返回parent的子entity
+func childrenOf(parent Entity) []Entity {
+	var children []Entity
+	ForeachChild(parent, func(child Entity) {
+		children = append(children, child)
+	})
+	return children
+}
+
+// 调用e[name]的销毁方法，map中的元素不能直接调用指针方法
+func destroyNamed(e map[string]Entity, name string, destroy func(*Entity)) {
+	entity := e[name]
+	destroy(&entity)
+}
+
+func TestSetParent(t *testing.T) {
+	world := NewWorld()
+	a, b, c := world.NewEntity(), world.NewEntity(), world.NewEntity()
+	SetParent(b, a)
+	SetParent(c, a)
+	if got := childrenOf(a); !slices.Equal(got, []Entity{b, c}) {
+		t.Errorf("children of a = %v, want [b c]", got)
+	}
+	// 重复设置相同的父entity不会重复加入子列表
+	SetParent(b, a)
+	if got := ChildCount(a); got != 2 {
+		t.Errorf("ChildCount(a) = %d, want 2", got)
+	}
+	// 换一个父entity
+	SetParent(c, b)
+	if parent, ok := GetParent(c); !ok || parent != b {
+		t.Errorf("GetParent(c) = %v, %v, want b, true", parent, ok)
+	}
+	if got := childrenOf(a); !slices.Equal(got, []Entity{b}) {
+		t.Errorf("children of a = %v, want [b]", got)
+	}
+	// 最后一个子entity移除时，ChildrenComponent被删除
+	if !RemoveParent(b) {
+		t.Errorf("RemoveParent(b) = false, want true")
+	}
+	if Has[ChildrenComponent](a) {
+		t.Errorf("a still has ChildrenComponent without children")
+	}
+	if RemoveParent(b) {
+		t.Errorf("RemoveParent of a root entity = true, want false")
+	}
+	var descendants []Entity
+	ForeachDescendant(b, func(entity Entity) bool {
+		descendants = append(descendants, entity)
+		return true
+	})
+	if !slices.Equal(descendants, []Entity{c}) {
+		t.Errorf("descendants of b = %v, want [c]", descendants)
+	}
+}
+
+func TestSetParentRejectsCycle(t *testing.T) {
+	tests := []struct {
+		name string
+		// 在a->b->c的层级上设置父entity
+		set func(a, b, c Entity)
+	}{
+		{name: "self", set: func(a, b, c Entity) { SetParent(a, a) }},
+		{name: "child", set: func(a, b, c Entity) { SetParent(a, b) }},
+		{name: "grandchild", set: func(a, b, c Entity) { SetParent(a, c) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			a, b, c := world.NewEntity(), world.NewEntity(), world.NewEntity()
+			SetParent(b, a)
+			SetParent(c, b)
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("SetParent did not panic")
+					}
+				}()
+				tt.set(a, b, c)
+			}()
+			// 层级保持不变
+			if _, ok := GetParent(a); ok {
+				t.Errorf("a has a parent after the rejected SetParent")
+			}
+			if parent, _ := GetParent(b); parent != a {
+				t.Errorf("parent of b = %v, want a", parent)
+			}
+			if parent, _ := GetParent(c); parent != b {
+				t.Errorf("parent of c = %v, want b", parent)
+			}
+		})
+	}
+}
+
+func TestDestroyHierarchy(t *testing.T) {
+	tests := []struct {
+		name string
+		// 在root->{a->{a1, a2}, b}的层级上销毁entity
+		destroy func(world *World, e map[string]Entity)
+		// 期望的EntityDestroying顺序
+		destroyed []string
+		// 期望的存活entity的父entity，空字符串表示根entity
+		parents map[string]string
+	}{
+		{
+			name:      "Destroy parent",
+			destroy:   func(world *World, e map[string]Entity) { destroyNamed(e, "a", (*Entity).Destroy) },
+			destroyed: []string{"a"},
+			parents:   map[string]string{"root": "", "a1": "", "a2": "", "b": "root"},
+		},
+		{
+			name:      "Destroy leaf",
+			destroy:   func(world *World, e map[string]Entity) { destroyNamed(e, "a1", (*Entity).Destroy) },
+			destroyed: []string{"a1"},
+			parents:   map[string]string{"root": "", "a": "root", "a2": "a", "b": "root"},
+		},
+		{
+			name:      "DestroyRecursive",
+			destroy:   func(world *World, e map[string]Entity) { destroyNamed(e, "a", (*Entity).DestroyRecursive) },
+			destroyed: []string{"a2", "a1", "a"},
+			parents:   map[string]string{"root": "", "b": "root"},
+		},
+		{
+			name:      "DestroyRecursive root",
+			destroy:   func(world *World, e map[string]Entity) { destroyNamed(e, "root", (*Entity).DestroyRecursive) },
+			destroyed: []string{"b", "a2", "a1", "a", "root"},
+			parents:   map[string]string{},
+		},
+		{
+			name: "Destroy parent while children are deferred",
+			destroy: func(world *World, e map[string]Entity) {
+				destroyNamed(e, "a1", (*Entity).DestroyDeferred)
+				destroyNamed(e, "a", (*Entity).Destroy)
+				world.FlushDestroyed()
+			},
+			destroyed: []string{"a", "a1"},
+			parents:   map[string]string{"root": "", "a2": "", "b": "root"},
+		},
+		{
+			name: "DestroyRecursive while children are deferred",
+			destroy: func(world *World, e map[string]Entity) {
+				destroyNamed(e, "a2", (*Entity).DestroyDeferred)
+				destroyNamed(e, "a", (*Entity).DestroyRecursive)
+				world.FlushDestroyed()
+			},
+			destroyed: []string{"a1", "a", "a2"},
+			parents:   map[string]string{"root": "", "b": "root"},
+		},
+		{
+			name: "parent and children deferred",
+			destroy: func(world *World, e map[string]Entity) {
+				destroyNamed(e, "a", (*Entity).DestroyDeferred)
+				destroyNamed(e, "a1", (*Entity).DestroyDeferred)
+				world.FlushDestroyed()
+			},
+			destroyed: []string{"a", "a1"},
+			parents:   map[string]string{"root": "", "a2": "", "b": "root"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			names := []string{"root", "a", "a1", "a2", "b"}
+			e := make(map[string]Entity)
+			nameOf := make(map[Entity]string)
+			for _, name := range names {
+				e[name] = world.NewEntity()
+				nameOf[e[name]] = name
+			}
+			SetParent(e["a"], e["root"])
+			SetParent(e["a1"], e["a"])
+			SetParent(e["a2"], e["a"])
+			SetParent(e["b"], e["root"])
+			var destroyed []string
+			world.OnEntityDestroying(func(entity Entity) {
+				destroyed = append(destroyed, nameOf[entity])
+			})
+
+			tt.destroy(world, e)
+			if !slices.Equal(destroyed, tt.destroyed) {
+				t.Errorf("destroyed = %v, want %v", destroyed, tt.destroyed)
+			}
+			for _, name := range names {
+				wantParent, alive := tt.parents[name]
+				entity := e[name]
+				if entity.IsAlive() != alive {
+					t.Errorf("%s.IsAlive() = %v, want %v", name, entity.IsAlive(), alive)
+					continue
+				}
+				if !alive {
+					continue
+				}
+				parent, ok := GetParent(e[name])
+				if got := nameOf[parent]; !ok && wantParent != "" || ok && got != wantParent {
+					t.Errorf("parent of %s = %q, want %q", name, got, wantParent)
+				}
+				// 子列表中不会残留已销毁的entity
+				for _, child := range childrenOf(e[name]) {
+					if !child.IsAlive() {
+						t.Errorf("children of %s contain destroyed %s", name, nameOf[child])
+					}
+				}
+			}
+		})
+	}
+}
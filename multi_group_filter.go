@@ -0,0 +1,203 @@
+package ecs
+
+import (
+	"iter"
+	"maps"
+	"slices"
+)
+
+// 接口功能：将Source转换为多个Key，比如背包组件中的所有物品类型、标签组件中的所有标签
+type IGroupKeysMap[Source any, Key comparable] interface {
+	MapKeys(Source) []Key
+}
+
+// 多值键值生成器：从Entity中提取多个Key
+type groupKeysMaker[Key comparable] interface {
+	makeKeys(Entity) []Key
+}
+
+// 将Source转换为多个Key的键值生成器
+// 实现groupKeysMaker[Key]接口
+type groupKeysMapper[Source any, Key comparable, Mapper IGroupKeysMap[Source, Key]] struct {
+	mapper Mapper
+}
+
+func (g groupKeysMapper[Source, Key, Mapper]) makeKeys(entity Entity) []Key {
+	return g.mapper.MapKeys(*Get[Source](entity))
+}
+
+// 由用户提供的函数从Entity中提取多个Key的键值生成器
+// 实现groupKeysMaker[Key]接口
+type funcKeysMaker[Key comparable] struct {
+	keysOf func(Entity) []Key
+}
+
+func (f funcKeysMaker[Key]) makeKeys(entity Entity) []Key {
+	return f.keysOf(entity)
+}
+
+// multiGroupFilterBase 与groupFilterBase类似，但是一个Entity可以同时属于多个Key的分组。
+// 它记录了每个Entity加入时的所有Key，移除Entity时根据记录清理所有分组，
+// 而不是根据当前的组件数据重新计算Key（此时组件数据可能已经被修改）。
+type multiGroupFilterBase[Key comparable, KeysMaker groupKeysMaker[Key]] struct {
+	groupKeySyncer
+	keysMaker KeysMaker
+	entities  map[Key]Set[Entity]
+	// 每个Entity所在的所有分组的Key
+	keysOf map[Entity][]Key
+}
+
+func newMultiGroupFilterBase[Key comparable, KeysMaker groupKeysMaker[Key]](filter IFilter) *multiGroupFilterBase[Key, KeysMaker] {
+	gf := &multiGroupFilterBase[Key, KeysMaker]{
+		groupKeySyncer: groupKeySyncer{world: filter.getWorld()},
+		entities:       make(map[Key]Set[Entity]),
+		keysOf:         make(map[Entity][]Key),
+	}
+	//监听filter的Entity增删事件，使用内部优先级，保证用户的监听被调用时分组已经更新
+	filter.AddListenerWithPriority(gf, PriorityInternal)
+	return gf
+}
+
+func (gf *multiGroupFilterBase[Key, KeysMaker]) iamGroupFilter() {}
+
+// 实现FilterEventListener接口
+func (gf *multiGroupFilterBase[Key, KeysMaker]) OnEntityAdded(entity Entity) {
+	// 已经在分组中的Entity，先移除旧的分组
+	gf.OnEntityRemoved(entity)
+
+	// 拷贝一份，keysMaker返回的可能是组件中的slice，并且去掉重复的key
+	var keys []Key
+	for _, key := range gf.keysMaker.makeKeys(entity) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	gf.keysOf[entity] = keys
+	for _, key := range keys {
+		set, ok := gf.entities[key]
+		if !ok {
+			set = make(Set[Entity])
+			gf.entities[key] = set
+		}
+		set.Add(entity)
+	}
+}
+
+// 实现FilterEventListener接口
+func (gf *multiGroupFilterBase[Key, KeysMaker]) OnEntityRemoved(entity Entity) {
+	keys, ok := gf.keysOf[entity]
+	if !ok {
+		return
+	}
+	delete(gf.keysOf, entity)
+	for _, key := range keys {
+		set := gf.entities[key]
+		delete(set, entity)
+		// 回收空的集合
+		if len(set) == 0 {
+			delete(gf.entities, key)
+		}
+	}
+}
+
+// 实现iEntitySet接口
+func (gf *multiGroupFilterBase[Key, KeysMaker]) Add(entity Entity) {
+	gf.OnEntityAdded(entity)
+}
+
+// 实现iEntitySet接口
+func (gf *multiGroupFilterBase[Key, KeysMaker]) Remove(entity Entity) {
+	gf.OnEntityRemoved(entity)
+}
+
+// 根据key找到任意一个Entity
+func (gf *multiGroupFilterBase[Key, KeysMaker]) FindOne(key Key) (Entity, bool) {
+	gf.syncGroupKeys()
+	for e := range gf.entities[key] {
+		return e, true
+	}
+	return Entity{}, false
+}
+
+// 遍历分组key中的所有Entity
+func (gf *multiGroupFilterBase[Key, KeysMaker]) Foreach(key Key, f func(Entity)) {
+	gf.syncGroupKeys()
+	for e := range gf.entities[key] {
+		f(e)
+	}
+}
+
+// KeysOf 返回Entity所在的所有分组的Key，Entity不在任何分组中时返回 nil。
+// 返回的slice不能修改。
+func (gf *multiGroupFilterBase[Key, KeysMaker]) KeysOf(entity Entity) []Key {
+	gf.syncGroupKeys()
+	return gf.keysOf[entity]
+}
+
+// Keys 返回所有的key（至少有一个Entity），顺序是不确定的
+func (gf *multiGroupFilterBase[Key, KeysMaker]) Keys() []Key {
+	gf.syncGroupKeys()
+	return slices.AppendSeq(make([]Key, 0, len(gf.entities)), maps.Keys(gf.entities))
+}
+
+// Count 返回分组key中的Entity数量
+func (gf *multiGroupFilterBase[Key, KeysMaker]) Count(key Key) int {
+	gf.syncGroupKeys()
+	return len(gf.entities[key])
+}
+
+// Len 返回分组（不同的key）的数量
+func (gf *multiGroupFilterBase[Key, KeysMaker]) Len() int {
+	gf.syncGroupKeys()
+	return len(gf.entities)
+}
+
+// ForeachGroup 遍历所有分组，参考groupFilterBase.ForeachGroup
+func (gf *multiGroupFilterBase[Key, KeysMaker]) ForeachGroup(f func(key Key, entities iter.Seq[Entity])) {
+	gf.syncGroupKeys()
+	for key, set := range gf.entities {
+		f(key, maps.Keys(set))
+	}
+}
+
+// MultiGroupFilterWithKeysMapper 按多个Key分组的过滤器，用于筛选持有KeyComp组件的Entity，
+// 并通过KeysMapper将KeyComp转换为多个Key，Entity会同时出现在每个Key的分组中。
+// 相当于Filter1[KeyComp].GroupByEach[KeysMapper(KeyComp)]。
+// 注意：其依赖于Filter1[KeyComp]，要先注册Filter1[KeyComp]；KeysMapper必须是struct类型！！
+type MultiGroupFilterWithKeysMapper[KeyComp any, Key comparable, KeysMapper IGroupKeysMap[KeyComp, Key]] struct {
+	*multiGroupFilterBase[Key, groupKeysMapper[KeyComp, Key, KeysMapper]]
+}
+
+func NewMultiGroupFilterWithKeysMapper[KeyComp any, Key comparable, KeysMapper IGroupKeysMap[KeyComp, Key]](world *World) *MultiGroupFilterWithKeysMapper[KeyComp, Key, KeysMapper] {
+	filter := GetFilter[*Filter1[KeyComp]](world)
+	gf := &MultiGroupFilterWithKeysMapper[KeyComp, Key, KeysMapper]{
+		newMultiGroupFilterBase[Key, groupKeysMapper[KeyComp, Key, KeysMapper]](filter),
+	}
+	//KeyComp是groupKey，监听其增删
+	registerGroupKeyEventByType[KeyComp](world, gf, filter)
+	return gf
+}
+
+// MultiGroupFilterFunc 与GroupFilterFunc类似，可以建立在任意已注册的IFilter之上，
+// 但是keysOf返回多个Key，Entity会同时出现在每个Key的分组中。
+type MultiGroupFilterFunc[Key comparable] struct {
+	*multiGroupFilterBase[Key, funcKeysMaker[Key]]
+}
+
+// NewMultiGroupFilterFunc 创建一个建立在filter之上的MultiGroupFilterFunc，参数的要求参考NewGroupFilterFunc。
+// 如果key来源于一个迭代器，可以使用slices.Collect转换为slice。
+func NewMultiGroupFilterFunc[Key comparable](world *World, filter IFilter, keysOf func(entity Entity) []Key, deps ...*ComponentType) *MultiGroupFilterFunc[Key] {
+	checkFilterDeps(world, filter, deps)
+	gf := &MultiGroupFilterFunc[Key]{
+		newMultiGroupFilterBase[Key, funcKeysMaker[Key]](filter),
+	}
+	gf.keysMaker = funcKeysMaker[Key]{keysOf: keysOf}
+	//deps中的组件是groupKey，监听其增删
+	for _, dep := range deps {
+		registerGroupKeyEventByTypeIndex(world, dep.TypeIndex, gf, filter)
+	}
+	return gf
+}
@@ -0,0 +1,145 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type multiTestTags struct {
+	Tags []string
+}
+
+type multiTestTagsMapper struct{}
+
+func (multiTestTagsMapper) MapKeys(tags multiTestTags) []string {
+	return tags.Tags
+}
+
+func init() {
+	RegisterComponentType[multiTestTags](16)
+}
+
+type multiTestFilter = MultiGroupFilterWithKeysMapper[multiTestTags, string, multiTestTagsMapper]
+
+func newMultiTestFilter() (*World, *multiTestFilter) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[multiTestTags](world))
+	return world, NewMultiGroupFilterWithKeysMapper[multiTestTags, string, multiTestTagsMapper](world)
+}
+
+func TestMultiGroupFilterKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   []string
+		modify func(entity Entity)
+		// 期望entity所在的分组
+		want []string
+		// 期望不存在的分组
+		gone []string
+	}{
+		{name: "dedup keys", tags: []string{"a", "a", "b", "a"}, want: []string{"a", "b"}},
+		{name: "no keys", tags: nil, want: nil},
+		{name: "Replace", tags: []string{"a", "b"}, modify: func(entity Entity) {
+			Replace(entity, multiTestTags{Tags: []string{"b", "c"}})
+		}, want: []string{"b", "c"}, gone: []string{"a"}},
+		{name: "GetForWrite", tags: []string{"a", "b"}, modify: func(entity Entity) {
+			tags := GetForWrite[multiTestTags](entity)
+			tags.Tags = append(tags.Tags[:0], "c")
+		}, want: []string{"c"}, gone: []string{"a", "b"}},
+		{name: "MarkDirty after in-place write", tags: []string{"a", "b"}, modify: func(entity Entity) {
+			Get[multiTestTags](entity).Tags[0] = "z"
+			MarkDirty[multiTestTags](entity)
+		}, want: []string{"z", "b"}, gone: []string{"a"}},
+		{name: "Replace with no keys", tags: []string{"a"}, modify: func(entity Entity) {
+			Replace(entity, multiTestTags{})
+		}, want: nil, gone: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, gf := newMultiTestFilter()
+			entity := world.NewEntity()
+			Replace(entity, multiTestTags{Tags: slices.Clone(tt.tags)})
+			if tt.modify != nil {
+				tt.modify(entity)
+			}
+			if got := gf.KeysOf(entity); !slices.Equal(got, tt.want) {
+				t.Errorf("KeysOf = %v, want %v", got, tt.want)
+			}
+			for _, key := range tt.want {
+				if got := gf.Count(key); got != 1 {
+					t.Errorf("Count(%q) = %d, want 1", key, got)
+				}
+				if found, ok := gf.FindOne(key); !ok || found != entity {
+					t.Errorf("FindOne(%q) = %v, %v, want %v, true", key, found, ok, entity)
+				}
+			}
+			for _, key := range tt.gone {
+				if got := gf.Count(key); got != 0 {
+					t.Errorf("Count(%q) = %d, want 0", key, got)
+				}
+			}
+			if got := gf.Len(); got != len(tt.want) {
+				t.Errorf("Len = %d, want %d", got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMultiGroupFilterRemoveByRecordedKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(entity Entity)
+	}{
+		{name: "Del", remove: func(entity Entity) { Del[multiTestTags](entity) }},
+		{name: "Destroy", remove: func(entity Entity) { entity.Destroy() }},
+		{name: "DestroyDeferred", remove: func(entity Entity) {
+			entity.DestroyDeferred()
+			entity.World().FlushDestroyed()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, gf := newMultiTestFilter()
+			entity, other := world.NewEntity(), world.NewEntity()
+			Replace(entity, multiTestTags{Tags: []string{"a", "b"}})
+			Replace(other, multiTestTags{Tags: []string{"b"}})
+			// 不通过写入路径修改组件，分组中记录的仍然是旧的key
+			Get[multiTestTags](entity).Tags = []string{"c"}
+			tt.remove(entity)
+			// 按记录的key移除，而不是按修改后的组件数据
+			if got, want := gf.Len(), 1; got != want {
+				t.Errorf("Len = %d, want %d, keys %v", got, want, gf.Keys())
+			}
+			if got := gf.Count("a"); got != 0 {
+				t.Errorf("Count(a) = %d, want 0", got)
+			}
+			if found, ok := gf.FindOne("b"); !ok || found != other {
+				t.Errorf("FindOne(b) = %v, %v, want %v, true", found, ok, other)
+			}
+			if got := gf.KeysOf(entity); got != nil {
+				t.Errorf("KeysOf removed entity = %v, want nil", got)
+			}
+		})
+	}
+}
+
+func TestMultiGroupFilterFunc(t *testing.T) {
+	world := NewWorld()
+	filter := RegisterFilter(world, NewFilter1[multiTestTags](world))
+	gf := NewMultiGroupFilterFunc(world, filter, func(entity Entity) []string {
+		return Get[multiTestTags](entity).Tags
+	}, GetComponentType[multiTestTags]())
+	entity := world.NewEntity()
+	Replace(entity, multiTestTags{Tags: []string{"a", "b"}})
+	Replace(entity, multiTestTags{Tags: []string{"c"}})
+	if got := gf.KeysOf(entity); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("KeysOf = %v, want [c]", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewMultiGroupFilterFunc did not panic for an unregistered filter")
+		}
+	}()
+	NewMultiGroupFilterFunc(world, NewFilter1[multiTestTags](world), func(entity Entity) []string { return nil })
+}
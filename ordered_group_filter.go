@@ -0,0 +1,56 @@
+package ecs
+
+import "cmp"
+
+// OrderedGroupFilter[KeyComp cmp.Ordered] 与GroupFilter[KeyComp]类似，
+// 用于筛选持有KeyComp组件的Entity，并以KeyComp组件数据作为key，
+// 区别在于它按key有序地存储Entity，支持Range、Min、Max、Ceil、Floor等有序查询。
+// 注意：其依赖于Filter1[KeyComp]，要先注册Filter1[KeyComp]。
+type OrderedGroupFilter[KeyComp cmp.Ordered] struct {
+	*orderedGroupFilterBase[KeyComp, DirectlyKeyMaker[KeyComp]]
+}
+
+func NewOrderedGroupFilter[KeyComp cmp.Ordered](world *World) *OrderedGroupFilter[KeyComp] {
+	filter := GetFilter[*Filter1[KeyComp]](world)
+	gf := &OrderedGroupFilter[KeyComp]{
+		newOrderedGroupFilterBase[KeyComp, DirectlyKeyMaker[KeyComp]](filter, cmp.Compare[KeyComp]),
+	}
+	//KeyComp是groupKey，监听其增删
+	registerGroupKeyEventByType[KeyComp](world, gf, filter)
+	return gf
+}
+
+// 与OrderedGroupFilter类似，但是通过键值转换器KeyMapper将KeyComp转换为有序的Key，
+// 比如从等级组件中取出等级数值作为key。
+// 注意：KeyMapper必须是struct类型！！
+type OrderedGroupFilterWithKeyMapper[KeyComp any, Key cmp.Ordered, KeyMapper IGroupKeyMap[KeyComp, Key]] struct {
+	*orderedGroupFilterBase[Key, groupKeyMapper[KeyComp, Key, KeyMapper]]
+}
+
+func NewOrderedGroupFilterWithKeyMapper[KeyComp any, Key cmp.Ordered, KeyMapper IGroupKeyMap[KeyComp, Key]](world *World) *OrderedGroupFilterWithKeyMapper[KeyComp, Key, KeyMapper] {
+	filter := GetFilter[*Filter1[KeyComp]](world)
+	gf := &OrderedGroupFilterWithKeyMapper[KeyComp, Key, KeyMapper]{
+		newOrderedGroupFilterBase[Key, groupKeyMapper[KeyComp, Key, KeyMapper]](filter, cmp.Compare[Key]),
+	}
+	//KeyComp是groupKey，监听其增删
+	registerGroupKeyEventByType[KeyComp](world, gf, filter)
+	return gf
+}
+
+// 与OrderedGroupFilterWithKeyMapper类似，但是Key的大小由比较器Comparator决定，
+// 用于Key不是cmp.Ordered的情况，比如按(分数降序, 时间升序)排序的联合key。
+// 注意：KeyMapper和Comparator都必须是struct类型！！
+type OrderedGroupFilterWithComparator[KeyComp any, Key comparable, KeyMapper IGroupKeyMap[KeyComp, Key], Comparator IKeyComparator[Key]] struct {
+	*orderedGroupFilterBase[Key, groupKeyMapper[KeyComp, Key, KeyMapper]]
+}
+
+func NewOrderedGroupFilterWithComparator[KeyComp any, Key comparable, KeyMapper IGroupKeyMap[KeyComp, Key], Comparator IKeyComparator[Key]](world *World) *OrderedGroupFilterWithComparator[KeyComp, Key, KeyMapper, Comparator] {
+	filter := GetFilter[*Filter1[KeyComp]](world)
+	var comparator Comparator
+	gf := &OrderedGroupFilterWithComparator[KeyComp, Key, KeyMapper, Comparator]{
+		newOrderedGroupFilterBase[Key, groupKeyMapper[KeyComp, Key, KeyMapper]](filter, comparator.Compare),
+	}
+	//KeyComp是groupKey，监听其增删
+	registerGroupKeyEventByType[KeyComp](world, gf, filter)
+	return gf
+}
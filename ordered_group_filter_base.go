@@ -0,0 +1,225 @@
+package ecs
+
+import (
+	"iter"
+	"maps"
+)
+
+// IKeyComparator 比较两个key的大小，a < b 返回负数，a == b 返回0，a > b 返回正数。
+// 与IGroupKeyMap一样，实现该接口的类型必须是struct类型（零值可用）。
+type IKeyComparator[Key any] interface {
+	Compare(a, b Key) int
+}
+
+// orderedGroupFilterBase 与groupFilterBase类似，也是一个按Key分组的Entity集合，
+// 区别在于它内部使用跳表按Key有序地存储，除了按key查询之外，还支持范围查询、最小/最大值查询等。
+// Key的大小由比较函数compare决定。
+type orderedGroupFilterBase[Key comparable, KeyMaker groupKeyMaker[Key]] struct {
+	groupKeySyncer
+	keyMaker KeyMaker
+	index    *skipList[Key]
+	// 每个Entity加入时的key，用于准确地移除Entity
+	keyOf map[Entity]Key
+}
+
+// 实例化一个orderedGroupFilterBase，需要传入所依赖的IFilter以及Key的比较函数。
+func newOrderedGroupFilterBase[Key comparable, KeyMaker groupKeyMaker[Key]](filter IFilter, compare func(a, b Key) int) *orderedGroupFilterBase[Key, KeyMaker] {
+	gf := &orderedGroupFilterBase[Key, KeyMaker]{
+		groupKeySyncer: groupKeySyncer{world: filter.getWorld()},
+		index:          newSkipList(compare),
+		keyOf:          make(map[Entity]Key),
+	}
+	//监听filter的Entity增删事件，使用内部优先级，保证用户的监听被调用时索引已经更新
+	filter.AddListenerWithPriority(gf, PriorityInternal)
+	return gf
+}
+
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) iamGroupFilter() {}
+
+// 实现FilterEventListener接口
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) OnEntityAdded(entity Entity) {
+	key := gf.keyMaker.makeKey(entity)
+	if oldKey, ok := gf.keyOf[entity]; ok {
+		if oldKey == key {
+			return
+		}
+		gf.index.remove(oldKey, entity)
+	}
+	gf.index.add(key, entity)
+	gf.keyOf[entity] = key
+}
+
+// 实现FilterEventListener接口，使用加入时记录的key移除
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) OnEntityRemoved(entity Entity) {
+	key, ok := gf.keyOf[entity]
+	if !ok {
+		return
+	}
+	delete(gf.keyOf, entity)
+	gf.index.remove(key, entity)
+}
+
+// 实现iEntitySet接口
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Add(entity Entity) {
+	gf.OnEntityAdded(entity)
+}
+
+// 实现iEntitySet接口
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Remove(entity Entity) {
+	gf.OnEntityRemoved(entity)
+}
+
+// 根据key找到任意一个Entity
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) FindOne(key Key) (Entity, bool) {
+	gf.syncGroupKeys()
+	return anyEntity(gf.index.find(key))
+}
+
+// 遍历keyMaker(entity)==key的所有Entity
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Foreach(key Key, f func(Entity)) {
+	gf.syncGroupKeys()
+	node := gf.index.find(key)
+	if node == nil {
+		return
+	}
+	for e := range node.entities {
+		f(e)
+	}
+}
+
+// Range 按key从小到大遍历 lo <= key <= hi 的所有Entity，f返回false时停止遍历。
+// key相同的Entity之间的顺序是不确定的。
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Range(lo, hi Key, f func(key Key, entity Entity) bool) {
+	gf.syncGroupKeys()
+	compare := gf.index.compare
+	for node := gf.index.ceil(lo); node != nil && compare(node.key, hi) <= 0; node = node.next[0] {
+		if !foreachNode(node, f) {
+			return
+		}
+	}
+}
+
+// RangeDesc 与Range相同，但是按key从大到小遍历
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) RangeDesc(lo, hi Key, f func(key Key, entity Entity) bool) {
+	gf.syncGroupKeys()
+	compare := gf.index.compare
+	for node := gf.index.floor(hi); node != nil && compare(node.key, lo) >= 0; node = node.prev {
+		if !foreachNode(node, f) {
+			return
+		}
+	}
+}
+
+// Ascend 按key从小到大遍历所有Entity，f返回false时停止遍历
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Ascend(f func(key Key, entity Entity) bool) {
+	gf.syncGroupKeys()
+	for node := gf.index.first(); node != nil; node = node.next[0] {
+		if !foreachNode(node, f) {
+			return
+		}
+	}
+}
+
+// Descend 按key从大到小遍历所有Entity，f返回false时停止遍历，比如用于排行榜取前N名
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Descend(f func(key Key, entity Entity) bool) {
+	gf.syncGroupKeys()
+	for node := gf.index.last(); node != nil; node = node.prev {
+		if !foreachNode(node, f) {
+			return
+		}
+	}
+}
+
+// Min 返回最小的key及其对应的任意一个Entity，没有Entity时返回false
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Min() (Key, Entity, bool) {
+	gf.syncGroupKeys()
+	return nodeKeyAndEntity(gf.index.first())
+}
+
+// Max 返回最大的key及其对应的任意一个Entity，没有Entity时返回false
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Max() (Key, Entity, bool) {
+	gf.syncGroupKeys()
+	return nodeKeyAndEntity(gf.index.last())
+}
+
+// Ceil 返回不小于key的最小key及其对应的任意一个Entity，不存在时返回false
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Ceil(key Key) (Key, Entity, bool) {
+	gf.syncGroupKeys()
+	return nodeKeyAndEntity(gf.index.ceil(key))
+}
+
+// Floor 返回不大于key的最大key及其对应的任意一个Entity，不存在时返回false
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Floor(key Key) (Key, Entity, bool) {
+	gf.syncGroupKeys()
+	return nodeKeyAndEntity(gf.index.floor(key))
+}
+
+// KeyOf 返回Entity所在分组的key，Entity不在分组中时返回false
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) KeyOf(entity Entity) (Key, bool) {
+	gf.syncGroupKeys()
+	key, ok := gf.keyOf[entity]
+	return key, ok
+}
+
+// Keys 按从小到大的顺序返回所有的key（至少有一个Entity）
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Keys() []Key {
+	gf.syncGroupKeys()
+	keys := make([]Key, 0, gf.index.len)
+	for node := gf.index.first(); node != nil; node = node.next[0] {
+		keys = append(keys, node.key)
+	}
+	return keys
+}
+
+// Count 返回key对应的Entity数量
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Count(key Key) int {
+	gf.syncGroupKeys()
+	node := gf.index.find(key)
+	if node == nil {
+		return 0
+	}
+	return len(node.entities)
+}
+
+// Len 返回分组（不同的key）的数量
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) Len() int {
+	gf.syncGroupKeys()
+	return gf.index.len
+}
+
+// ForeachGroup 按key从小到大遍历所有分组，entities为该分组中的Entity。
+// 注意，entities只在f执行期间有效，遍历期间不要修改分组的key。
+func (gf *orderedGroupFilterBase[Key, KeyMaker]) ForeachGroup(f func(key Key, entities iter.Seq[Entity])) {
+	gf.syncGroupKeys()
+	for node := gf.index.first(); node != nil; node = node.next[0] {
+		f(node.key, maps.Keys(node.entities))
+	}
+}
+
+// 遍历节点中的所有Entity，f返回false时停止遍历并返回false
+func foreachNode[Key any](node *skipListNode[Key], f func(key Key, entity Entity) bool) bool {
+	for e := range node.entities {
+		if !f(node.key, e) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyEntity[Key any](node *skipListNode[Key]) (Entity, bool) {
+	if node != nil {
+		for e := range node.entities {
+			return e, true
+		}
+	}
+	return Entity{}, false
+}
+
+func nodeKeyAndEntity[Key any](node *skipListNode[Key]) (Key, Entity, bool) {
+	if node == nil {
+		var key Key
+		return key, Entity{}, false
+	}
+	e, _ := anyEntity(node)
+	return node.key, e, true
+}
@@ -0,0 +1,60 @@
+package ecs
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func TestOrderedGroupFilterRange(t *testing.T) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[groupTestKey](world))
+	gf := NewOrderedGroupFilterWithComparator[groupTestKey, groupTestKey, orderedTestKeyMapper, orderedTestComparator](world)
+	for _, v := range []int{5, 1, 9, 3, 7, 3} {
+		Replace(world.NewEntity(), groupTestKey{V: v})
+	}
+	collect := func(query func(f func(key groupTestKey, entity Entity) bool)) []int {
+		var result []int
+		query(func(key groupTestKey, entity Entity) bool {
+			result = append(result, key.V)
+			return true
+		})
+		return result
+	}
+	tests := []struct {
+		name  string
+		query func(f func(key groupTestKey, entity Entity) bool)
+		want  []int
+	}{
+		{name: "Range", query: func(f func(groupTestKey, Entity) bool) { gf.Range(groupTestKey{V: 2}, groupTestKey{V: 7}, f) }, want: []int{3, 3, 5, 7}},
+		{name: "RangeDesc", query: func(f func(groupTestKey, Entity) bool) { gf.RangeDesc(groupTestKey{V: 2}, groupTestKey{V: 7}, f) }, want: []int{7, 5, 3, 3}},
+		{name: "Ascend", query: gf.Ascend, want: []int{1, 3, 3, 5, 7, 9}},
+		{name: "Descend", query: gf.Descend, want: []int{9, 7, 5, 3, 3, 1}},
+		{name: "empty Range", query: func(f func(groupTestKey, Entity) bool) { gf.Range(groupTestKey{V: 10}, groupTestKey{V: 20}, f) }, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collect(tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if key, _, ok := gf.Ceil(groupTestKey{V: 4}); !ok || key.V != 5 {
+		t.Errorf("Ceil(4) = %v, %v, want 5", key, ok)
+	}
+	if key, _, ok := gf.Floor(groupTestKey{V: 4}); !ok || key.V != 3 {
+		t.Errorf("Floor(4) = %v, %v, want 3", key, ok)
+	}
+}
+
+type orderedTestKeyMapper struct{}
+
+func (orderedTestKeyMapper) MapKey(k groupTestKey) groupTestKey {
+	return k
+}
+
+type orderedTestComparator struct{}
+
+func (orderedTestComparator) Compare(a, b groupTestKey) int {
+	return cmp.Compare(a.V, b.V)
+}
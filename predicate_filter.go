@@ -0,0 +1,172 @@
+package ecs
+
+import "slices"
+
+// PredicateFilter 基于组件数据的过滤器，在所依赖的filter的基础上，进一步要求Entity满足谓词predicate，
+// 比如“生命值小于等于0”、“年龄大于18”。
+// 谓词所读取的组件（deps）被Replace或通过写入路径（GetForWrite、MarkDirty等）修改时，会重新求值，
+// Entity进出PredicateFilter时会通知OnAdd/OnRemove等监听，可以用来实现事件驱动的死亡处理、buff过期等。
+// 通过写入路径修改的组件不会立即重新求值（写入发生在GetForWrite返回之后），而是在以下时机重新求值并触发监听：
+//   - 查询PredicateFilter（Foreach、Len、Contains、Snapshot）时；
+//   - World.FlushDestroyed（每帧结束时）或者World.SyncGroupKeys时。
+type PredicateFilter struct {
+	groupKeySyncer
+	predicate func(entity Entity) bool
+	// 满足谓词的Entity
+	entities []Entity
+	// Entity在entities中的下标
+	entitiesMap map[Entity]int
+	// 过滤器新增/删除entity时的事件监听，外部可以通过OnAdd/OnRemove方法注册
+	eventListen *FilterEventListen
+	// 外部可以通过AddListener方法注册监听
+	listeners callbackList[FilterEventListener]
+}
+
+// NewPredicateFilter 在filter的基础上创建一个PredicateFilter，filter必须已经注册到world中。
+// predicate只能读取组件数据（Get），不能修改world；
+// deps为predicate所读取的组件类型（GetComponentType[T]()），filter中的Entity都必须拥有这些组件。
+func NewPredicateFilter(filter IFilter, predicate func(entity Entity) bool, deps ...*ComponentType) *PredicateFilter {
+	world := filter.getWorld()
+	pf := &PredicateFilter{
+		groupKeySyncer: groupKeySyncer{world: world},
+		predicate:      predicate,
+		entitiesMap:    make(map[Entity]int),
+	}
+	// 过滤器中已有的Entity
+	if snapshotter, ok := filter.(interface{ Snapshot() []Entity }); ok {
+		for _, entity := range snapshotter.Snapshot() {
+			pf.Add(entity)
+		}
+	}
+	//监听filter的Entity增删事件，使用内部优先级，保证filter的用户监听被调用时PredicateFilter已经更新
+	filter.AddListenerWithPriority(pf, PriorityInternal)
+	//deps中的组件变化时重新求值
+	for _, dep := range deps {
+		registerGroupKeyEventByTypeIndex(world, dep.TypeIndex, pf, filter)
+	}
+	return pf
+}
+
+// NewPredicateFilter1 创建一个筛选T组件满足predicate的Entity的PredicateFilter，
+// 比如 NewPredicateFilter1(world, func(hp *Health) bool { return hp.Val <= 0 })。
+// 注意：其依赖于Filter1[T]，要先注册Filter1[T]。
+func NewPredicateFilter1[T any](world *World, predicate func(comp *T) bool) *PredicateFilter {
+	filter := GetFilter[*Filter1[T]](world)
+	return NewPredicateFilter(filter, func(entity Entity) bool {
+		return predicate(Get[T](entity))
+	}, GetComponentType[T]())
+}
+
+// 实现FilterEventListener接口
+func (pf *PredicateFilter) OnEntityAdded(entity Entity) {
+	pf.Add(entity)
+}
+
+// 实现FilterEventListener接口
+func (pf *PredicateFilter) OnEntityRemoved(entity Entity) {
+	pf.removeEntity(entity)
+}
+
+// 实现iEntitySet接口：Entity加入了所依赖的filter，或者deps中的组件被修改，重新求值
+func (pf *PredicateFilter) Add(entity Entity) {
+	if pf.predicate(entity) {
+		pf.addEntity(entity)
+	} else {
+		pf.removeEntity(entity)
+	}
+}
+
+// 实现iEntitySet接口：deps中的组件即将被修改，此时不处理，等修改完成后（Add）再重新求值
+func (pf *PredicateFilter) Remove(entity Entity) {}
+
+func (pf *PredicateFilter) addEntity(entity Entity) {
+	if _, ok := pf.entitiesMap[entity]; ok {
+		return
+	}
+	pf.entitiesMap[entity] = len(pf.entities)
+	pf.entities = append(pf.entities, entity)
+	pf.listeners.foreach(func(listener FilterEventListener) {
+		listener.OnEntityAdded(entity)
+	})
+}
+
+func (pf *PredicateFilter) removeEntity(entity Entity) {
+	idx, ok := pf.entitiesMap[entity]
+	if !ok {
+		return
+	}
+	//直接将末尾的元素移到被删除的位置
+	last := len(pf.entities) - 1
+	pf.entities[idx] = pf.entities[last]
+	pf.entitiesMap[pf.entities[idx]] = idx
+	pf.entities = pf.entities[:last]
+	delete(pf.entitiesMap, entity)
+	pf.listeners.foreach(func(listener FilterEventListener) {
+		listener.OnEntityRemoved(entity)
+	})
+}
+
+// Len 返回满足谓词的Entity数量
+func (pf *PredicateFilter) Len() int {
+	pf.syncGroupKeys()
+	return len(pf.entities)
+}
+
+// Contains 判断entity是否满足谓词
+func (pf *PredicateFilter) Contains(entity Entity) bool {
+	pf.syncGroupKeys()
+	_, ok := pf.entitiesMap[entity]
+	return ok
+}
+
+// Snapshot 返回所有满足谓词的Entity的拷贝
+func (pf *PredicateFilter) Snapshot() []Entity {
+	pf.syncGroupKeys()
+	return slices.Clone(pf.entities)
+}
+
+// Foreach 遍历所有满足谓词的Entity。
+// 遍历的是开始遍历时的Entity，遍历期间可以修改组件或者销毁Entity，比如在死亡处理中销毁Entity，
+// 已经不满足谓词的Entity会被跳过。
+func (pf *PredicateFilter) Foreach(f func(entity Entity)) {
+	for _, entity := range pf.Snapshot() {
+		if _, ok := pf.entitiesMap[entity]; ok {
+			f(entity)
+		}
+	}
+}
+
+// 外部想监听Entity进出PredicateFilter的事件，可用此方法注册，返回的Subscription用于移除监听
+func (pf *PredicateFilter) AddListener(listener FilterEventListener) Subscription {
+	return pf.listeners.add(listener)
+}
+
+// AddListenerWithPriority 按优先级注册监听，参考filterBase.AddListenerWithPriority
+func (pf *PredicateFilter) AddListenerWithPriority(listener FilterEventListener, priority int) Subscription {
+	return pf.listeners.addWithPriority(listener, priority)
+}
+
+// 移除通过AddListener注册的监听
+func (pf *PredicateFilter) RemoveListener(listener FilterEventListener) {
+	pf.listeners.removeFunc(func(l FilterEventListener) bool {
+		return l == listener
+	})
+}
+
+// OnAdd 监听Entity开始满足谓词（加入PredicateFilter），返回的Subscription用于移除监听
+func (pf *PredicateFilter) OnAdd(cb func(entity Entity)) Subscription {
+	if pf.eventListen == nil {
+		pf.eventListen = newFilterEventListener()
+		pf.AddListener(pf.eventListen)
+	}
+	return pf.eventListen.EntityAdded.AddCallback(cb)
+}
+
+// OnRemove 监听Entity不再满足谓词（包括离开所依赖的filter、被销毁），返回的Subscription用于移除监听
+func (pf *PredicateFilter) OnRemove(cb func(entity Entity)) Subscription {
+	if pf.eventListen == nil {
+		pf.eventListen = newFilterEventListener()
+		pf.AddListener(pf.eventListen)
+	}
+	return pf.eventListen.EntityRemoved.AddCallback(cb)
+}
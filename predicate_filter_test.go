@@ -0,0 +1,71 @@
+package ecs
+
+import "testing"
+
+type predicateTestHp struct {
+	Val int
+}
+
+func init() {
+	RegisterComponentType[predicateTestHp](16)
+}
+
+func TestPredicateFilterEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		// 写入之后的同步时机
+		sync func(world *World, pf *PredicateFilter)
+	}{
+		{name: "FlushDestroyed", sync: func(world *World, pf *PredicateFilter) { world.FlushDestroyed() }},
+		{name: "SyncGroupKeys", sync: func(world *World, pf *PredicateFilter) { world.SyncGroupKeys() }},
+		{name: "Len", sync: func(world *World, pf *PredicateFilter) { pf.Len() }},
+		{name: "Foreach", sync: func(world *World, pf *PredicateFilter) { pf.Foreach(func(Entity) {}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			RegisterFilter(world, NewFilter1[predicateTestHp](world))
+			dead := NewPredicateFilter1(world, func(hp *predicateTestHp) bool { return hp.Val <= 0 })
+			var added, removed []Entity
+			dead.OnAdd(func(entity Entity) { added = append(added, entity) })
+			dead.OnRemove(func(entity Entity) { removed = append(removed, entity) })
+			entity := world.NewEntity()
+			Replace(entity, predicateTestHp{Val: 10})
+
+			GetForWrite[predicateTestHp](entity).Val = 0
+			tt.sync(world, dead)
+			if len(added) != 1 || added[0] != entity {
+				t.Fatalf("added = %v, want [%v]", added, entity)
+			}
+			GetForWrite[predicateTestHp](entity).Val = 5
+			tt.sync(world, dead)
+			if len(removed) != 1 || removed[0] != entity {
+				t.Fatalf("removed = %v, want [%v]", removed, entity)
+			}
+		})
+	}
+}
+
+func TestPredicateFilterDestroyInHandler(t *testing.T) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[predicateTestHp](world))
+	dead := NewPredicateFilter1(world, func(hp *predicateTestHp) bool { return hp.Val <= 0 })
+	// 事件驱动的死亡处理：生命值降到0时延迟销毁
+	dead.OnAdd(func(entity Entity) { entity.DestroyDeferred() })
+	entities := make([]Entity, 3)
+	for i := range entities {
+		entities[i] = world.NewEntity()
+		Replace(entities[i], predicateTestHp{Val: 10})
+	}
+	GetForWrite[predicateTestHp](entities[0]).Val = 0
+	Replace(entities[2], predicateTestHp{Val: -1})
+	world.FlushDestroyed()
+	for i, want := range []bool{false, true, false} {
+		if got := entities[i].IsAlive(); got != want {
+			t.Errorf("entities[%d].IsAlive() = %v, want %v", i, got, want)
+		}
+	}
+	if got := dead.Len(); got != 0 {
+		t.Errorf("Len = %d, want 0", got)
+	}
+}
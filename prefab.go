@@ -0,0 +1,101 @@
+package ecs
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Prefab 实体模板，记录了一组组件数据。
+// 通过World.Instantiate可以基于模板创建entity，所有组件会被批量地应用，相关过滤器只更新一次。
+// Prefab可以在代码中构建（NewPrefab/Set），也可以从数据中加载（LoadPrefab/LoadPrefabJSON）。
+// 注意，组件类型必须在构建/加载Prefab之前注册。
+type Prefab struct {
+	components []componentValue
+}
+
+// NewPrefab 使用一组组件值创建一个Prefab，components中的每个元素都必须是已注册的组件值（不能是指针）。
+func NewPrefab(components ...any) *Prefab {
+	p := &Prefab{}
+	for _, component := range components {
+		p.Set(component)
+	}
+	return p
+}
+
+// LoadPrefab 从数据中加载一个Prefab。
+// data的键为组件类型名称（不含包名），值为组件的序列化数据，使用unmarshal进行反序列化。
+func LoadPrefab(data map[string][]byte, unmarshal func([]byte, any) error) (*Prefab, error) {
+	p := &Prefab{}
+	for name, raw := range data {
+		componentType, err := getComponentTypeByName(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := componentType.decode(raw, unmarshal)
+		if err != nil {
+			return nil, err
+		}
+		p.set(componentValue{componentType: componentType, value: value})
+	}
+	// map的遍历顺序是随机的，按组件类型索引排序，保证每次加载的结果一致
+	slices.SortFunc(p.components, func(a, b componentValue) int {
+		return a.componentType.TypeIndex - b.componentType.TypeIndex
+	})
+	return p, nil
+}
+
+// LoadPrefabJSON 从JSON数据中加载一个Prefab，
+// 格式如：{"NameComponent": {"First": "xx"}, "AgeComponent": {"Val": 18}}
+func LoadPrefabJSON(data []byte) (*Prefab, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	components := make(map[string][]byte, len(raw))
+	for name, v := range raw {
+		components[name] = v
+	}
+	return LoadPrefab(components, json.Unmarshal)
+}
+
+// Set 设置模板中的组件数据，模板中已存在同类型的组件则替换。
+// 返回Prefab自身，以便链式调用。
+func (p *Prefab) Set(component any) *Prefab {
+	p.set(newComponentValue(component))
+	return p
+}
+
+func (p *Prefab) set(cv componentValue) {
+	for i, c := range p.components {
+		if c.componentType == cv.componentType {
+			p.components[i] = cv
+			return
+		}
+	}
+	p.components = append(p.components, cv)
+}
+
+// Len 返回模板中的组件数量
+func (p *Prefab) Len() int {
+	return len(p.components)
+}
+
+// Instantiate 基于prefab创建一个新的entity。
+// overrides用于覆盖模板中同类型的组件数据，也可以是模板中没有的组件。
+// 模板中的组件数据会按组件的拷贝语义（参考DeepCopier）拷贝给新entity，
+// 所有组件会被批量地应用到entity上，相关过滤器只更新一次。
+func (w *World) Instantiate(prefab *Prefab, overrides ...any) Entity {
+	components := make([]componentValue, 0, len(prefab.components)+len(overrides))
+	// 每个entity都拷贝一份模板中的组件数据，避免entity之间共享slice/map
+	for _, c := range prefab.components {
+		components = append(components, componentValue{componentType: c.componentType, value: c.componentType.cloneValue(c.value)})
+	}
+	for _, override := range overrides {
+		components = append(components, newComponentValue(override))
+	}
+
+	entity := w.newEntity()
+	applyComponents(w, entity, w.getEntityData(entity.Id), components, nil)
+	w.Events.EntityCreated.Invoke(entity)
+	return entity
+}
@@ -0,0 +1,143 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type prefabTestName struct {
+	First string
+}
+
+type prefabTestAge struct {
+	Val int
+}
+
+type prefabTestInventory struct {
+	Items []string
+}
+
+type prefabTestDead struct{}
+
+func init() {
+	RegisterComponentType[prefabTestName](16)
+	RegisterComponentType[prefabTestAge](16)
+	RegisterComponentType[prefabTestInventory](16)
+	RegisterComponentType[prefabTestDead](16)
+}
+
+func TestInstantiate(t *testing.T) {
+	prefab := NewPrefab(prefabTestName{First: "npc"}, prefabTestAge{Val: 18}, prefabTestInventory{Items: []string{"sword"}})
+	tests := []struct {
+		name      string
+		overrides []any
+		wantName  string
+		wantAge   int
+		wantDead  bool
+	}{
+		{name: "no overrides", wantName: "npc", wantAge: 18},
+		{name: "override existing", overrides: []any{prefabTestAge{Val: 30}}, wantName: "npc", wantAge: 30},
+		{name: "override twice", overrides: []any{prefabTestAge{Val: 30}, prefabTestAge{Val: 40}}, wantName: "npc", wantAge: 40},
+		{name: "add new component", overrides: []any{prefabTestDead{}, prefabTestName{First: "boss"}}, wantName: "boss", wantAge: 18, wantDead: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			filter := RegisterFilter(world, NewFilter2[prefabTestName, prefabTestAge](world))
+			alive := RegisterFilter(world, NewFilter1Exclude[prefabTestName, prefabTestDead](world))
+			filterEvents := recordFilterEvents(filter)
+			aliveEvents := recordFilterEvents(alive)
+			// 创建事件触发时，所有组件都已经应用
+			var createdAge int
+			world.OnEntityCreated(func(entity Entity) {
+				createdAge = Get[prefabTestAge](entity).Val
+			})
+
+			entity := world.Instantiate(prefab, tt.overrides...)
+			if got := Get[prefabTestName](entity).First; got != tt.wantName {
+				t.Errorf("name = %q, want %q", got, tt.wantName)
+			}
+			if got := Get[prefabTestAge](entity).Val; got != tt.wantAge {
+				t.Errorf("age = %d, want %d", got, tt.wantAge)
+			}
+			if got := Has[prefabTestDead](entity); got != tt.wantDead {
+				t.Errorf("Has dead = %v, want %v", got, tt.wantDead)
+			}
+			if createdAge != tt.wantAge {
+				t.Errorf("age in EntityCreated = %d, want %d", createdAge, tt.wantAge)
+			}
+			// 每个过滤器只被操作一次，排斥过滤器不会先加入再移除
+			if len(filterEvents.added) != 1 || len(filterEvents.removed) != 0 {
+				t.Errorf("filter added %d, removed %d, want 1, 0", len(filterEvents.added), len(filterEvents.removed))
+			}
+			wantAlive := 1
+			if tt.wantDead {
+				wantAlive = 0
+			}
+			if len(aliveEvents.added) != wantAlive || len(aliveEvents.removed) != 0 {
+				t.Errorf("exclude filter added %d, removed %d, want %d, 0", len(aliveEvents.added), len(aliveEvents.removed), wantAlive)
+			}
+		})
+	}
+}
+
+func TestInstantiateCopiesPerInstance(t *testing.T) {
+	world := NewWorld()
+	prefab := NewPrefab(prefabTestInventory{Items: []string{"sword"}})
+	a, b := world.Instantiate(prefab), world.Instantiate(prefab)
+	Get[prefabTestInventory](a).Items[0] = "axe"
+	GetForWrite[prefabTestInventory](a).Items = append(Get[prefabTestInventory](a).Items, "shield")
+	if got := Get[prefabTestInventory](b).Items; !slices.Equal(got, []string{"sword"}) {
+		t.Errorf("items of another instance = %v, want [sword]", got)
+	}
+	// 模板不受影响，之后的实例仍然使用模板的数据
+	if got := Get[prefabTestInventory](world.Instantiate(prefab)).Items; !slices.Equal(got, []string{"sword"}) {
+		t.Errorf("items of a new instance = %v, want [sword]", got)
+	}
+	// Set替换模板中同类型的组件
+	prefab.Set(prefabTestInventory{Items: []string{"bow"}})
+	if prefab.Len() != 1 {
+		t.Errorf("prefab Len = %d, want 1", prefab.Len())
+	}
+	if got := Get[prefabTestInventory](world.Instantiate(prefab)).Items; !slices.Equal(got, []string{"bow"}) {
+		t.Errorf("items after Set = %v, want [bow]", got)
+	}
+}
+
+func TestLoadPrefabJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "valid", data: `{"prefabTestName": {"First": "npc"}, "prefabTestAge": {"Val": 18}, "prefabTestInventory": {"Items": ["sword"]}}`},
+		{name: "unknown component", data: `{"prefabTestUnknown": {}}`, wantErr: true},
+		{name: "bad component data", data: `{"prefabTestAge": {"Val": "old"}}`, wantErr: true},
+		{name: "bad json", data: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefab, err := LoadPrefabJSON([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("LoadPrefabJSON succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPrefabJSON: %v", err)
+			}
+			world := NewWorld()
+			entity := world.Instantiate(prefab, prefabTestAge{Val: 20})
+			if got := Get[prefabTestName](entity).First; got != "npc" {
+				t.Errorf("name = %q, want npc", got)
+			}
+			if got := Get[prefabTestAge](entity).Val; got != 20 {
+				t.Errorf("age = %d, want 20", got)
+			}
+			if got := Get[prefabTestInventory](entity).Items; !slices.Equal(got, []string{"sword"}) {
+				t.Errorf("items = %v, want [sword]", got)
+			}
+		})
+	}
+}
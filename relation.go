@@ -0,0 +1,232 @@
+package ecs
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// RelationCleanupPolicy 关系的目标entity被销毁时，对关系的处理策略
+type RelationCleanupPolicy int
+
+const (
+	// RelationCleanupRemove 目标entity销毁时，仅移除(relation, target)对，源entity不受影响
+	RelationCleanupRemove RelationCleanupPolicy = iota
+	// RelationCleanupDestroySource 目标entity销毁时，同时销毁持有该关系的源entity
+	RelationCleanupDestroySource
+)
+
+var RelationTypeIndex int
+
+// 所有已注册的关系类型，//<关系反射类型, 关系类型数据>
+var relationTypeMap = make(map[reflect.Type]*RelationType)
+
+// 关系类型数据
+// 关系类型R一般是一个空结构体，仅用于标识关系的种类，比如 type Likes struct{}
+type RelationType struct {
+	// 关系类型索引，关系类型的索引是唯一的
+	TypeIndex int
+	// 关系反射类型
+	Type reflect.Type
+	// 目标entity被销毁时的处理策略
+	CleanupPolicy RelationCleanupPolicy
+}
+
+// 注册关系类型
+func RegisterRelationType[R any](policy RelationCleanupPolicy) *RelationType {
+	t := reflect.TypeOf((*R)(nil)).Elem()
+	if _, ok := relationTypeMap[t]; ok {
+		panic(fmt.Sprintf("relation:%s repeat register", t.Name()))
+	}
+	RelationTypeIndex++
+	rt := &RelationType{
+		TypeIndex:     RelationTypeIndex,
+		Type:          t,
+		CleanupPolicy: policy,
+	}
+	relationTypeMap[t] = rt
+	return rt
+}
+
+// 获取关系类型数据
+func GetRelationType[R any]() *RelationType {
+	t := reflect.TypeOf((*R)(nil)).Elem()
+	relationType, ok := relationTypeMap[t]
+	if !ok {
+		panic(fmt.Sprintf("relation:%+v not register", t.Name()))
+	}
+	return relationType
+}
+
+// relationStore 存储某一种关系的所有(source, target)对，
+// 同时维护正反两个方向的索引，以便按源entity或目标entity快速查询。
+type relationStore struct {
+	relationType *RelationType
+	// <源entity, 目标entity集合>
+	targets map[Entity]Set[Entity]
+	// <目标entity, 源entity集合>
+	sources map[Entity]Set[Entity]
+}
+
+func newRelationStore(relationType *RelationType) *relationStore {
+	return &relationStore{
+		relationType: relationType,
+		targets:      make(map[Entity]Set[Entity]),
+		sources:      make(map[Entity]Set[Entity]),
+	}
+}
+
+func (rs *relationStore) add(source, target Entity) {
+	targets, ok := rs.targets[source]
+	if !ok {
+		targets = make(Set[Entity])
+		rs.targets[source] = targets
+	}
+	targets.Add(target)
+	sources, ok := rs.sources[target]
+	if !ok {
+		sources = make(Set[Entity])
+		rs.sources[target] = sources
+	}
+	sources.Add(source)
+}
+
+func (rs *relationStore) remove(source, target Entity) bool {
+	targets, ok := rs.targets[source]
+	if !ok || !targets.Contains(target) {
+		return false
+	}
+	targets.Remove(target)
+	if len(targets) == 0 {
+		delete(rs.targets, source)
+	}
+	sources := rs.sources[target]
+	sources.Remove(source)
+	if len(sources) == 0 {
+		delete(rs.sources, target)
+	}
+	return true
+}
+
+// 移除entity作为源entity的所有关系
+func (rs *relationStore) removeSource(source Entity) {
+	for target := range rs.targets[source] {
+		rs.remove(source, target)
+	}
+}
+
+// 移除entity作为目标entity的所有关系，返回被移除关系的源entity
+func (rs *relationStore) removeTarget(target Entity) []Entity {
+	sources, ok := rs.sources[target]
+	if !ok {
+		return nil
+	}
+	removed := make([]Entity, 0, len(sources))
+	for source := range sources {
+		removed = append(removed, source)
+	}
+	// 集合的遍历顺序是随机的，按entity的Id排序，保证结果是确定的
+	slices.SortFunc(removed, func(a, b Entity) int {
+		return a.Id - b.Id
+	})
+	for _, source := range removed {
+		rs.remove(source, target)
+	}
+	return removed
+}
+
+// 获取关系R在world中的存储，create为 true 时不存在则创建
+func getRelationStore[R any](w *World, create bool) *relationStore {
+	relationType := GetRelationType[R]()
+	store, ok := w.relations[relationType.TypeIndex]
+	if !ok && create {
+		store = newRelationStore(relationType)
+		w.relations[relationType.TypeIndex] = store
+	}
+	return store
+}
+
+// AddRelation 为source添加关系R(target)，比如AddRelation[Likes](a, b)表示a喜欢b。
+// source和target必须属于同一个World，并且都是存活的。
+func AddRelation[R any](source, target Entity) {
+	if source.WorldPtr != target.WorldPtr {
+		panic("source and target must belong to the same world")
+	}
+	if !source.IsAlive() || !target.IsAlive() {
+		panic("entity is not alive")
+	}
+	getRelationStore[R](source.World(), true).add(source, target)
+}
+
+// RemoveRelation 移除source的关系R(target)，关系不存在时返回 false。
+func RemoveRelation[R any](source, target Entity) bool {
+	store := getRelationStore[R](source.World(), false)
+	if store == nil {
+		return false
+	}
+	return store.remove(source, target)
+}
+
+// HasRelation 检查source是否拥有关系R(target)。
+func HasRelation[R any](source, target Entity) bool {
+	store := getRelationStore[R](source.World(), false)
+	if store == nil {
+		return false
+	}
+	return store.targets[source].Contains(target)
+}
+
+// TargetOf 获取source的关系R的任意一个目标entity，适用于Targets这类只有一个目标的关系。
+func TargetOf[R any](source Entity) (Entity, bool) {
+	store := getRelationStore[R](source.World(), false)
+	if store == nil {
+		return Entity{}, false
+	}
+	for target := range store.targets[source] {
+		return target, true
+	}
+	return Entity{}, false
+}
+
+// ForeachTarget 遍历source的关系R的所有目标entity，比如e所拥有（Owns）的所有entity。
+// 注意，遍历期间不要增删关系R。
+func ForeachTarget[R any](source Entity, f func(target Entity)) {
+	store := getRelationStore[R](source.World(), false)
+	if store == nil {
+		return
+	}
+	for target := range store.targets[source] {
+		f(target)
+	}
+}
+
+// ForeachSource 遍历所有拥有关系R(target)的源entity，比如所有以target为攻击目标（Targets）的entity。
+// 注意，遍历期间不要增删关系R。
+func ForeachSource[R any](target Entity, f func(source Entity)) {
+	store := getRelationStore[R](target.World(), false)
+	if store == nil {
+		return
+	}
+	for source := range store.sources[target] {
+		f(source)
+	}
+}
+
+// cleanupRelations 在entity销毁前清理其所有关系：
+// 作为源entity的关系直接移除；作为目标entity的关系，按关系类型的CleanupPolicy处理。
+// 按关系类型索引的顺序处理，需要连带销毁的源entity按Id的顺序销毁，保证销毁的顺序（以及销毁事件的顺序）是确定的。
+func (w *World) cleanupRelations(entity Entity) {
+	var destroySources []Entity
+	for _, typeIndex := range slices.Sorted(maps.Keys(w.relations)) {
+		store := w.relations[typeIndex]
+		store.removeSource(entity)
+		sources := store.removeTarget(entity)
+		if store.relationType.CleanupPolicy == RelationCleanupDestroySource {
+			destroySources = append(destroySources, sources...)
+		}
+	}
+	for _, source := range destroySources {
+		destroyEntity(source)
+	}
+}
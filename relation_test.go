@@ -0,0 +1,142 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+// 目标entity销毁时连带销毁源entity的关系
+type relationTestOwnedBy struct{}
+
+// 与relationTestOwnedBy相同，用于验证多个关系类型的处理顺序
+type relationTestBoundTo struct{}
+
+// 目标entity销毁时只移除关系的关系
+type relationTestLikes struct{}
+
+func init() {
+	RegisterRelationType[relationTestOwnedBy](RelationCleanupDestroySource)
+	RegisterRelationType[relationTestBoundTo](RelationCleanupDestroySource)
+	RegisterRelationType[relationTestLikes](RelationCleanupRemove)
+}
+
+// 记录EntityDestroying事件的顺序，返回的函数将其转换为entity在entities中的下标
+func recordDestroying(world *World, entities []Entity) func() []int {
+	var destroying []Entity
+	world.OnEntityDestroying(func(entity Entity) {
+		destroying = append(destroying, entity)
+	})
+	return func() []int {
+		order := make([]int, 0, len(destroying))
+		for _, entity := range destroying {
+			order = append(order, slices.Index(entities, entity))
+		}
+		return order
+	}
+}
+
+func TestRelationCleanupDestroySource(t *testing.T) {
+	tests := []struct {
+		name string
+		// 建立关系，销毁entities[0]
+		relate func(entities []Entity)
+		// 期望的EntityDestroying顺序
+		want []int
+		// 期望存活的entity
+		alive []int
+	}{
+		{
+			name: "sources in id order",
+			relate: func(e []Entity) {
+				AddRelation[relationTestOwnedBy](e[3], e[0])
+				AddRelation[relationTestOwnedBy](e[1], e[0])
+				AddRelation[relationTestOwnedBy](e[4], e[0])
+				AddRelation[relationTestOwnedBy](e[2], e[0])
+			},
+			want: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name: "relation types in type index order",
+			relate: func(e []Entity) {
+				AddRelation[relationTestBoundTo](e[1], e[0])
+				AddRelation[relationTestBoundTo](e[2], e[0])
+				AddRelation[relationTestOwnedBy](e[4], e[0])
+				AddRelation[relationTestOwnedBy](e[3], e[0])
+			},
+			want: []int{0, 3, 4, 1, 2},
+		},
+		{
+			name: "chain",
+			relate: func(e []Entity) {
+				AddRelation[relationTestOwnedBy](e[1], e[0])
+				AddRelation[relationTestOwnedBy](e[2], e[1])
+				AddRelation[relationTestOwnedBy](e[3], e[2])
+			},
+			want:  []int{0, 1, 2, 3},
+			alive: []int{4},
+		},
+		{
+			name: "cycle",
+			relate: func(e []Entity) {
+				AddRelation[relationTestOwnedBy](e[1], e[0])
+				AddRelation[relationTestOwnedBy](e[0], e[1])
+			},
+			want:  []int{0, 1},
+			alive: []int{2, 3, 4},
+		},
+		{
+			name: "remove policy keeps sources",
+			relate: func(e []Entity) {
+				AddRelation[relationTestLikes](e[1], e[0])
+				AddRelation[relationTestOwnedBy](e[2], e[1])
+			},
+			want:  []int{0},
+			alive: []int{1, 2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// map的遍历顺序是随机的，多次运行以验证顺序是确定的
+			for range 20 {
+				world := NewWorld()
+				entities := make([]Entity, 5)
+				for i := range entities {
+					entities[i] = world.NewEntity()
+				}
+				order := recordDestroying(world, entities)
+				tt.relate(entities)
+				entities[0].Destroy()
+				if got := order(); !slices.Equal(got, tt.want) {
+					t.Fatalf("destroy order = %v, want %v", got, tt.want)
+				}
+				for i, entity := range entities {
+					if want := slices.Contains(tt.alive, i); entity.IsAlive() != want {
+						t.Fatalf("entities[%d].IsAlive() = %v, want %v", i, entity.IsAlive(), want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRelationRemovedWithEntity(t *testing.T) {
+	world := NewWorld()
+	a, b, c := world.NewEntity(), world.NewEntity(), world.NewEntity()
+	AddRelation[relationTestLikes](a, b)
+	AddRelation[relationTestLikes](c, a)
+	AddRelation[relationTestLikes](c, b)
+	a.Destroy()
+	if HasRelation[relationTestLikes](c, a) {
+		t.Errorf("relation to the destroyed target is not removed")
+	}
+	if !HasRelation[relationTestLikes](c, b) {
+		t.Errorf("unrelated relation is removed")
+	}
+	var sources []Entity
+	ForeachSource[relationTestLikes](b, func(source Entity) {
+		sources = append(sources, source)
+	})
+	if !slices.Equal(sources, []Entity{c}) {
+		t.Errorf("sources of b = %v, want [c]", sources)
+	}
+}
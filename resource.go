@@ -0,0 +1,166 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// 资源（Resource）是World级别的全局数据，每种类型在一个World中最多只有一个，
+// 比如帧间隔时间、配置、随机数生成器、导航网格等。
+// 与组件不同，资源类型不需要注册，也不属于任何entity。
+
+// ResourceType 返回资源类型T的反射类型，用于标识资源，比如声明系统对资源的读写（ResourceAccess）
+func ResourceType[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// SetResource 设置world中类型为T的资源，已存在则覆盖。
+// 返回world中存储的资源指针，可以直接通过它修改资源。
+func SetResource[T any](w *World, v T) *T {
+	t := ResourceType[T]()
+	if res, ok := w.resources[t]; ok {
+		p := res.(*T)
+		*p = v
+		return p
+	}
+	p := &v
+	w.resources[t] = p
+	return p
+}
+
+// Resource 获取world中类型为T的资源，资源不存在则panic。
+// 返回的指针在资源被DelResource删除之前一直有效，覆盖（SetResource）不会改变指针。
+func Resource[T any](w *World) *T {
+	p, ok := TryResource[T](w)
+	if !ok {
+		panic(fmt.Sprintf("resource %v not found", ResourceType[T]()))
+	}
+	return p
+}
+
+// TryResource 获取world中类型为T的资源，资源不存在时返回 nil, false
+func TryResource[T any](w *World) (*T, bool) {
+	res, ok := w.resources[ResourceType[T]()]
+	if !ok {
+		return nil, false
+	}
+	return res.(*T), true
+}
+
+// HasResource world中是否存在类型为T的资源
+func HasResource[T any](w *World) bool {
+	_, ok := w.resources[ResourceType[T]()]
+	return ok
+}
+
+// DelResource 删除world中类型为T的资源，返回资源是否存在
+func DelResource[T any](w *World) bool {
+	t := ResourceType[T]()
+	if _, ok := w.resources[t]; !ok {
+		return false
+	}
+	delete(w.resources, t)
+	return true
+}
+
+// ForeachResource 遍历world中的所有资源，value为资源指针（*T），
+// 可用于调试等需要访问全部资源的场景，保存资源请使用SaveResources。遍历顺序是不确定的。
+func (w *World) ForeachResource(f func(t reflect.Type, value any)) {
+	for t, res := range w.resources {
+		f(t, res)
+	}
+}
+
+// 可序列化的资源类型，键为资源类型名称（不含包名）
+var serializableResources = make(map[string]*serializableResource)
+
+type serializableResource struct {
+	t reflect.Type
+	// 使用指定的反序列化函数，将data解析为资源并设置到world中
+	load func(w *World, data []byte, unmarshal func([]byte, any) error) error
+}
+
+// RegisterResourceType 注册可序列化的资源类型T，只有注册过的资源才会被SaveResources保存、被LoadResources加载，
+// 运行时临时的资源（比如随机数生成器、缓存）不需要注册。
+// 资源以类型名称（不含包名）标识，与LoadPrefab中的组件名称一样，名称相同的资源类型只能注册一个。
+func RegisterResourceType[T any]() {
+	t := ResourceType[T]()
+	if registered, ok := serializableResources[t.Name()]; ok && registered.t != t {
+		panic(fmt.Sprintf("resource name:%s is ambiguous", t.Name()))
+	}
+	serializableResources[t.Name()] = &serializableResource{
+		t: t,
+		load: func(w *World, data []byte, unmarshal func([]byte, any) error) error {
+			var v T
+			if err := unmarshal(data, &v); err != nil {
+				return err
+			}
+			SetResource(w, v)
+			return nil
+		},
+	}
+}
+
+// SaveResources 使用marshal序列化world中所有注册过的资源（参考RegisterResourceType），
+// 返回值的键为资源类型名称，值为资源的序列化数据，可以与entity的数据一起保存，之后通过LoadResources加载。
+func (w *World) SaveResources(marshal func(any) ([]byte, error)) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	for name, sr := range serializableResources {
+		res, ok := w.resources[sr.t]
+		if !ok {
+			continue
+		}
+		raw, err := marshal(res)
+		if err != nil {
+			return nil, fmt.Errorf("save resource:%s: %w", name, err)
+		}
+		data[name] = raw
+	}
+	return data, nil
+}
+
+// LoadResources 加载SaveResources保存的资源，使用unmarshal进行反序列化。
+// 已存在的资源会被覆盖，资源指针保持不变（参考SetResource）；data中有未注册的资源类型时返回错误。
+func (w *World) LoadResources(data map[string][]byte, unmarshal func([]byte, any) error) error {
+	// 按名称的顺序加载，保证出错时的结果是确定的
+	for _, name := range slices.Sorted(maps.Keys(data)) {
+		sr, ok := serializableResources[name]
+		if !ok {
+			return fmt.Errorf("resource:%s is not registered", name)
+		}
+		if err := sr.load(w, data[name], unmarshal); err != nil {
+			return fmt.Errorf("load resource:%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SaveResourcesJSON 以JSON格式保存world中所有注册过的资源，
+// 格式如：{"GameTime": {"Tick": 100}, "GameConfig": {"MaxPlayer": 10}}
+func (w *World) SaveResourcesJSON() ([]byte, error) {
+	data, err := w.SaveResources(json.Marshal)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]json.RawMessage, len(data))
+	for name, v := range data {
+		raw[name] = v
+	}
+	return json.Marshal(raw)
+}
+
+// LoadResourcesJSON 加载SaveResourcesJSON保存的资源
+func (w *World) LoadResourcesJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	resources := make(map[string][]byte, len(raw))
+	for name, v := range raw {
+		resources[name] = v
+	}
+	return w.LoadResources(resources, json.Unmarshal)
+}
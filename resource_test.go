@@ -0,0 +1,119 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type resourceTestTime struct {
+	Tick int
+}
+
+type resourceTestConfig struct {
+	Name string
+	Max  int
+}
+
+// 没有注册，不会被保存
+type resourceTestCache struct {
+	Hits int
+}
+
+func init() {
+	RegisterResourceType[resourceTestTime]()
+	RegisterResourceType[resourceTestConfig]()
+}
+
+func TestResourcesSaveLoadJSON(t *testing.T) {
+	src := NewWorld()
+	SetResource(src, resourceTestTime{Tick: 100})
+	SetResource(src, resourceTestConfig{Name: "test", Max: 10})
+	SetResource(src, resourceTestCache{Hits: 3})
+	data, err := src.SaveResourcesJSON()
+	if err != nil {
+		t.Fatalf("SaveResourcesJSON: %v", err)
+	}
+
+	dst := NewWorld()
+	// 已存在的资源被覆盖，指针保持不变
+	tm := SetResource(dst, resourceTestTime{Tick: 1})
+	if err := dst.LoadResourcesJSON(data); err != nil {
+		t.Fatalf("LoadResourcesJSON: %v", err)
+	}
+	if tm.Tick != 100 || Resource[resourceTestTime](dst) != tm {
+		t.Errorf("time = %+v, want Tick 100 at the same pointer", *Resource[resourceTestTime](dst))
+	}
+	if got := *Resource[resourceTestConfig](dst); got != (resourceTestConfig{Name: "test", Max: 10}) {
+		t.Errorf("config = %+v", got)
+	}
+	if HasResource[resourceTestCache](dst) {
+		t.Errorf("unregistered resource is saved")
+	}
+}
+
+func TestLoadResourcesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "unregistered", data: `{"resourceTestCache": {"Hits": 1}}`},
+		{name: "bad value", data: `{"resourceTestTime": {"Tick": "x"}}`},
+		{name: "bad json", data: `{`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewWorld().LoadResourcesJSON([]byte(tt.data)); err == nil {
+				t.Errorf("LoadResourcesJSON(%s) = nil, want error", tt.data)
+			}
+		})
+	}
+}
+
+type resourceTestSystem struct {
+	access *ResourceAccess
+}
+
+func (s *resourceTestSystem) Update() {}
+
+func (s *resourceTestSystem) ResourceAccess() *ResourceAccess {
+	return s.access
+}
+
+type resourceTestPlainSystem struct{}
+
+func (resourceTestPlainSystem) Update() {}
+
+func TestSystemsConflict(t *testing.T) {
+	timeType := ResourceType[resourceTestTime]()
+	configType := ResourceType[resourceTestConfig]()
+	system := func(access *ResourceAccess) Systemer {
+		return &resourceTestSystem{access: access}
+	}
+	tests := []struct {
+		name string
+		a, b Systemer
+		want bool
+	}{
+		{name: "read read", a: system(new(ResourceAccess).Read(timeType)), b: system(new(ResourceAccess).Read(timeType)), want: false},
+		{name: "read write", a: system(new(ResourceAccess).Read(timeType)), b: system(new(ResourceAccess).Write(timeType)), want: true},
+		{name: "write read", a: system(new(ResourceAccess).Write(timeType)), b: system(new(ResourceAccess).Read(timeType)), want: true},
+		{name: "write write", a: system(new(ResourceAccess).Write(configType)), b: system(new(ResourceAccess).Write(configType)), want: true},
+		{name: "disjoint", a: system(new(ResourceAccess).Write(timeType)), b: system(new(ResourceAccess).Write(configType)), want: false},
+		{name: "undeclared", a: resourceTestPlainSystem{}, b: system(new(ResourceAccess)), want: true},
+		// ResourceAccess返回 nil 表示不读写任何资源
+		{name: "nil write", a: system(nil), b: system(new(ResourceAccess).Write(timeType)), want: false},
+		{name: "write nil", a: system(new(ResourceAccess).Write(timeType)), b: system(nil), want: false},
+		{name: "nil nil", a: system(nil), b: system(nil), want: false},
+		{name: "nil undeclared", a: system(nil), b: resourceTestPlainSystem{}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SystemsConflict(tt.a, tt.b); got != tt.want {
+				t.Errorf("SystemsConflict = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := ResourceType[resourceTestTime](); got != reflect.TypeOf(resourceTestTime{}) {
+		t.Errorf("ResourceType = %v", got)
+	}
+}
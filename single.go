@@ -0,0 +1,42 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// 查找world中唯一持有T组件的entity，返回entity及其在Filter1[T]中的下标。
+// 要求Filter1[T]已经注册，没有或者有多个entity持有T组件时panic。
+func single[T any](w *World) (*Filter1[T], int) {
+	filterName := reflect.TypeOf((*Filter1[T])(nil)).Elem().Name()
+	filter, ok := w.filters[filterName]
+	if !ok {
+		panic(fmt.Sprintf("Single[%v]: filter %s not registered", reflect.TypeOf((*T)(nil)).Elem(), filterName))
+	}
+	f := filter.(*Filter1[T])
+	switch count := f.Count(); count {
+	case 1:
+		return f, 0
+	case 0:
+		panic(fmt.Sprintf("Single[%v]: no entity found", reflect.TypeOf((*T)(nil)).Elem()))
+	default:
+		panic(fmt.Sprintf("Single[%v]: %d entities found, expect exactly one", reflect.TypeOf((*T)(nil)).Elem(), count))
+	}
+}
+
+// Single 获取world中唯一持有T组件的entity及其T组件（拷贝），适用于单例组件，比如玩家、摄像机等。
+// 要求Filter1[T]已经注册（RegisterFilter(w, NewFilter1[T](w))），
+// 没有或者有多个entity持有T组件时panic。
+func Single[T any](w *World) (Entity, T) {
+	f, idx := single[T](w)
+	return f.entityAt(idx), *f.include1.GetItem(idx)
+}
+
+// SingleMut 与Single相同，但返回的是组件指针，可以直接修改组件数据。
+// 与GetForWrite一样，会触发组件更新前的事件，并更新以T为key的groupFilter等索引。
+// 注意，不要持有返回的组件指针，参考TryGet的注释。
+func SingleMut[T any](w *World) (Entity, *T) {
+	f, idx := single[T](w)
+	entity := f.entityAt(idx)
+	return entity, GetForWrite[T](entity)
+}
@@ -0,0 +1,80 @@
+package ecs
+
+import "testing"
+
+type singleTestPlayer struct {
+	Name string
+}
+
+func init() {
+	RegisterComponentType[singleTestPlayer](16)
+}
+
+func TestSingle(t *testing.T) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[singleTestPlayer](world))
+	group := RegisterGroupFilter(world, NewGroupFilter[singleTestPlayer](world))
+	player := world.NewEntity()
+	Replace(player, singleTestPlayer{Name: "a"})
+	world.NewEntity()
+
+	entity, comp := Single[singleTestPlayer](world)
+	if entity != player || comp.Name != "a" {
+		t.Errorf("Single = %v, %+v, want player, {Name:a}", entity, comp)
+	}
+	// Single返回的是拷贝
+	comp.Name = "b"
+	if got := Get[singleTestPlayer](player).Name; got != "a" {
+		t.Errorf("name after modifying the copy = %q, want a", got)
+	}
+
+	// SingleMut的写入会更新以该组件为key的索引
+	entity, ptr := SingleMut[singleTestPlayer](world)
+	if entity != player {
+		t.Errorf("SingleMut entity = %v, want player", entity)
+	}
+	ptr.Name = "b"
+	if found, ok := group.FindOne(singleTestPlayer{Name: "b"}); !ok || found != player {
+		t.Errorf("FindOne(b) = %v, %v, want player, true", found, ok)
+	}
+	if _, ok := group.FindOne(singleTestPlayer{Name: "a"}); ok {
+		t.Errorf("FindOne(a) found an entity after SingleMut")
+	}
+}
+
+func TestSinglePanics(t *testing.T) {
+	tests := []struct {
+		name string
+		// 持有singleTestPlayer的entity数量
+		count    int
+		register bool
+	}{
+		{name: "no entity", count: 0, register: true},
+		{name: "two entities", count: 2, register: true},
+		{name: "filter not registered", count: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			if tt.register {
+				RegisterFilter(world, NewFilter1[singleTestPlayer](world))
+			}
+			for range tt.count {
+				Replace(world.NewEntity(), singleTestPlayer{})
+			}
+			for name, single := range map[string]func(){
+				"Single":    func() { Single[singleTestPlayer](world) },
+				"SingleMut": func() { SingleMut[singleTestPlayer](world) },
+			} {
+				func() {
+					defer func() {
+						if recover() == nil {
+							t.Errorf("%s did not panic", name)
+						}
+					}()
+					single()
+				}()
+			}
+		})
+	}
+}
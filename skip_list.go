@@ -0,0 +1,164 @@
+package ecs
+
+import "math/rand/v2"
+
+// 跳表的最大层数，足够容纳数百万个不同的key
+const skipListMaxLevel = 24
+
+type skipListNode[Key any] struct {
+	key Key
+	// key相同的所有entity
+	entities Set[Entity]
+	// 第i层的下一个节点
+	next []*skipListNode[Key]
+	// 第0层的上一个节点，第一个节点的prev为 nil，用于逆序遍历
+	prev *skipListNode[Key]
+}
+
+// skipList 有序的跳表，每个节点存储一个key及该key对应的entity集合，
+// 用于支持按key的范围查询、最小/最大值查询等。
+type skipList[Key any] struct {
+	compare func(a, b Key) int
+	// 头节点不存储数据
+	head *skipListNode[Key]
+	// 最后一个节点，跳表为空时为 nil
+	tail *skipListNode[Key]
+	// 当前的层数
+	level int
+	// 节点（不同key）的数量
+	len int
+}
+
+func newSkipList[Key any](compare func(a, b Key) int) *skipList[Key] {
+	return &skipList[Key]{
+		compare: compare,
+		head:    &skipListNode[Key]{next: make([]*skipListNode[Key], skipListMaxLevel)},
+		level:   1,
+	}
+}
+
+// 随机生成新节点的层数，第i层的概率为 1/4^(i-1)
+func (l *skipList[Key]) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Uint32()&3 == 0 {
+		level++
+	}
+	return level
+}
+
+// 查找key，update[i]为第i层中最后一个小于key的节点，
+// 返回第一个不小于key的节点，不存在则返回 nil
+func (l *skipList[Key]) search(key Key, update *[skipListMaxLevel]*skipListNode[Key]) *skipListNode[Key] {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && l.compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+// 查找key对应的节点，不存在则返回 nil
+func (l *skipList[Key]) find(key Key) *skipListNode[Key] {
+	x := l.search(key, nil)
+	if x != nil && l.compare(x.key, key) == 0 {
+		return x
+	}
+	return nil
+}
+
+// 返回第一个key不小于key的节点，不存在则返回 nil
+func (l *skipList[Key]) ceil(key Key) *skipListNode[Key] {
+	return l.search(key, nil)
+}
+
+// 返回最后一个key不大于key的节点，不存在则返回 nil
+func (l *skipList[Key]) floor(key Key) *skipListNode[Key] {
+	var update [skipListMaxLevel]*skipListNode[Key]
+	x := l.search(key, &update)
+	if x != nil && l.compare(x.key, key) == 0 {
+		return x
+	}
+	if update[0] == l.head {
+		return nil
+	}
+	return update[0]
+}
+
+// 返回key最小的节点，跳表为空时返回 nil
+func (l *skipList[Key]) first() *skipListNode[Key] {
+	return l.head.next[0]
+}
+
+// 返回key最大的节点，跳表为空时返回 nil
+func (l *skipList[Key]) last() *skipListNode[Key] {
+	return l.tail
+}
+
+// 将entity加入key对应的节点，节点不存在则创建
+func (l *skipList[Key]) add(key Key, entity Entity) {
+	var update [skipListMaxLevel]*skipListNode[Key]
+	x := l.search(key, &update)
+	if x != nil && l.compare(x.key, key) == 0 {
+		x.entities.Add(entity)
+		return
+	}
+
+	level := l.randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			update[i] = l.head
+		}
+		l.level = level
+	}
+	node := &skipListNode[Key]{
+		key:      key,
+		entities: Set[Entity]{entity: {}},
+		next:     make([]*skipListNode[Key], level),
+	}
+	for i := range level {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	if update[0] != l.head {
+		node.prev = update[0]
+	}
+	if node.next[0] != nil {
+		node.next[0].prev = node
+	} else {
+		l.tail = node
+	}
+	l.len++
+}
+
+// 将entity从key对应的节点中移除，节点为空时回收该节点
+func (l *skipList[Key]) remove(key Key, entity Entity) {
+	var update [skipListMaxLevel]*skipListNode[Key]
+	x := l.search(key, &update)
+	if x == nil || l.compare(x.key, key) != 0 {
+		return
+	}
+	x.entities.Remove(entity)
+	if len(x.entities) > 0 {
+		return
+	}
+
+	for i := range l.level {
+		if update[i].next[i] != x {
+			break
+		}
+		update[i].next[i] = x.next[i]
+	}
+	if x.next[0] != nil {
+		x.next[0].prev = x.prev
+	} else {
+		l.tail = x.prev
+	}
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	l.len--
+}
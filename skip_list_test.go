@@ -0,0 +1,128 @@
+package ecs
+
+import (
+	"cmp"
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// 检查跳表与参考模型model一致
+func checkSkipList(t *testing.T, l *skipList[int], model map[int]Set[Entity]) {
+	t.Helper()
+	keys := slices.Sorted(maps.Keys(model))
+	if l.len != len(keys) {
+		t.Fatalf("len = %d, want %d", l.len, len(keys))
+	}
+	// 正序
+	var forward []int
+	for node := l.first(); node != nil; node = node.next[0] {
+		forward = append(forward, node.key)
+		if !maps.Equal(node.entities, model[node.key]) {
+			t.Fatalf("entities of key %d = %v, want %v", node.key, node.entities, model[node.key])
+		}
+	}
+	if !slices.Equal(forward, keys) {
+		t.Fatalf("forward = %v, want %v", forward, keys)
+	}
+	// 逆序
+	var backward []int
+	for node := l.last(); node != nil; node = node.prev {
+		backward = append(backward, node.key)
+	}
+	slices.Reverse(backward)
+	if !slices.Equal(backward, keys) {
+		t.Fatalf("backward = %v, want %v", backward, keys)
+	}
+	// 每一层都是有序的
+	for level := 1; level < skipListMaxLevel; level++ {
+		for node := l.head.next[level]; node != nil && node.next[level] != nil; node = node.next[level] {
+			if node.key >= node.next[level].key {
+				t.Fatalf("level %d is not sorted: %d >= %d", level, node.key, node.next[level].key)
+			}
+		}
+	}
+}
+
+func TestSkipListAgainstModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		seed     int64
+		keyRange int
+		ops      int
+	}{
+		{name: "few keys", seed: 1, keyRange: 8, ops: 500},
+		{name: "many keys", seed: 2, keyRange: 1000, ops: 5000},
+		{name: "sparse", seed: 3, keyRange: 1 << 20, ops: 2000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(tt.seed))
+			l := newSkipList(cmp.Compare[int])
+			model := make(map[int]Set[Entity])
+			for i := range tt.ops {
+				key := rng.Intn(tt.keyRange)
+				entity := Entity{Id: rng.Intn(4), Gen: 1}
+				if rng.Intn(3) == 0 {
+					l.remove(key, entity)
+					if set, ok := model[key]; ok {
+						set.Remove(entity)
+						if len(set) == 0 {
+							delete(model, key)
+						}
+					}
+				} else {
+					l.add(key, entity)
+					if _, ok := model[key]; !ok {
+						model[key] = make(Set[Entity])
+					}
+					model[key].Add(entity)
+				}
+				if i%100 == 0 {
+					checkSkipList(t, l, model)
+				}
+			}
+			checkSkipList(t, l, model)
+
+			keys := slices.Sorted(maps.Keys(model))
+			for probe := -1; probe <= min(tt.keyRange, 2000); probe++ {
+				_, found := model[probe]
+				if node := l.find(probe); (node != nil) != found {
+					t.Fatalf("find(%d) = %v, want found %v", probe, node, found)
+				}
+				// ceil：不小于probe的最小key
+				i, _ := slices.BinarySearch(keys, probe)
+				if node := l.ceil(probe); i < len(keys) {
+					if node == nil || node.key != keys[i] {
+						t.Fatalf("ceil(%d) = %v, want %d", probe, node, keys[i])
+					}
+				} else if node != nil {
+					t.Fatalf("ceil(%d) = %d, want nil", probe, node.key)
+				}
+				// floor：不大于probe的最大key
+				j, ok := slices.BinarySearch(keys, probe)
+				if !ok {
+					j--
+				}
+				if node := l.floor(probe); j >= 0 {
+					if node == nil || node.key != keys[j] {
+						t.Fatalf("floor(%d) = %v, want %d", probe, node, keys[j])
+					}
+				} else if node != nil {
+					t.Fatalf("floor(%d) = %d, want nil", probe, node.key)
+				}
+			}
+
+			// 全部移除后回到空表
+			for key, set := range model {
+				for entity := range set {
+					l.remove(key, entity)
+				}
+			}
+			if l.len != 0 || l.first() != nil || l.last() != nil || l.level != 1 {
+				t.Fatalf("not empty after removing all: len %d, level %d", l.len, l.level)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package ecs
+
+import "slices"
+
+// SortedView 过滤器的有序视图，按照用户提供的比较函数对过滤器中Entity的T组件排序，
+// 比如按z-index渲染、按先攻值决定行动顺序。
+// 视图是增量维护的：Entity进出过滤器、T组件被Replace或通过写入路径（GetForWrite、MarkDirty等）修改时，
+// 只会重新排列这些Entity，而不是每次遍历都重新排序。
+// 注意，直接通过Get获得的指针修改T组件而不调用MarkDirty，视图的顺序不会更新。
+type SortedView[T any] struct {
+	groupKeySyncer
+	compare func(a, b *T) int
+	// 有序的Entity，其中可能包含待移除的Entity（stale）
+	order []Entity
+	// 在order中并且位置有效的Entity
+	positioned Set[Entity]
+	// 在order中、等待移除的Entity
+	stale Set[Entity]
+	// 等待插入order的Entity
+	pending Set[Entity]
+}
+
+// SortBy 创建filter的有序视图，filter中的Entity都必须拥有T组件，比如 SortBy[Sprite](spriteFilter, ...)。
+// compare比较两个T组件，a < b 返回负数，a == b 返回0，a > b 返回正数；
+// compare只能读取组件数据，不能修改world。filter必须已经注册到world中。
+func SortBy[T any](filter IFilter, compare func(a, b *T) int) *SortedView[T] {
+	componentType := GetComponentType[T]()
+	if !slices.Contains(filter.getIncludeTypeIndices(), componentType.TypeIndex) {
+		panic("the filter does not include the sort component")
+	}
+	world := filter.getWorld()
+	view := &SortedView[T]{
+		groupKeySyncer: groupKeySyncer{world: world},
+		compare:        compare,
+		positioned:     make(Set[Entity]),
+		stale:          make(Set[Entity]),
+		pending:        make(Set[Entity]),
+	}
+	// 过滤器中已有的Entity
+	if snapshotter, ok := filter.(interface{ Snapshot() []Entity }); ok {
+		for _, entity := range snapshotter.Snapshot() {
+			view.Add(entity)
+		}
+	}
+	//监听filter的Entity增删事件，使用内部优先级，保证用户的监听被调用时视图已经更新
+	filter.AddListenerWithPriority(view, PriorityInternal)
+	//T是排序的key，监听其变化
+	registerGroupKeyEventByType[T](world, view, filter)
+	return view
+}
+
+// 实现FilterEventListener接口
+func (v *SortedView[T]) OnEntityAdded(entity Entity) {
+	v.Add(entity)
+}
+
+// 实现FilterEventListener接口
+func (v *SortedView[T]) OnEntityRemoved(entity Entity) {
+	v.Remove(entity)
+}
+
+// 实现iEntitySet接口
+func (v *SortedView[T]) Add(entity Entity) {
+	if v.positioned.Contains(entity) {
+		return
+	}
+	v.pending.Add(entity)
+}
+
+// 实现iEntitySet接口
+func (v *SortedView[T]) Remove(entity Entity) {
+	if v.pending.Contains(entity) {
+		v.pending.Remove(entity)
+		return
+	}
+	if v.positioned.Contains(entity) {
+		v.positioned.Remove(entity)
+		v.stale.Add(entity)
+	}
+}
+
+// 移除待移除的Entity，插入等待插入的Entity
+func (v *SortedView[T]) flush() {
+	v.syncGroupKeys()
+	if len(v.stale) > 0 {
+		// 生成新的slice而不是原地删除，Foreach中可能正在遍历旧的slice
+		order := make([]Entity, 0, len(v.order)-len(v.stale))
+		for _, entity := range v.order {
+			if !v.stale.Contains(entity) {
+				order = append(order, entity)
+			}
+		}
+		v.order = order
+		clear(v.stale)
+	}
+	if len(v.pending) == 0 {
+		return
+	}
+
+	compareEntity := func(a, b Entity) int {
+		return v.compare(Get[T](a), Get[T](b))
+	}
+	added := make([]Entity, 0, len(v.pending))
+	for entity := range v.pending {
+		added = append(added, entity)
+		v.positioned.Add(entity)
+	}
+	clear(v.pending)
+	slices.SortStableFunc(added, compareEntity)
+
+	// 归并两个有序的序列，相等时已有的Entity在前
+	merged := make([]Entity, 0, len(v.order)+len(added))
+	i, j := 0, 0
+	for i < len(v.order) && j < len(added) {
+		if compareEntity(added[j], v.order[i]) < 0 {
+			merged = append(merged, added[j])
+			j++
+		} else {
+			merged = append(merged, v.order[i])
+			i++
+		}
+	}
+	merged = append(merged, v.order[i:]...)
+	merged = append(merged, added[j:]...)
+	v.order = merged
+}
+
+// Len 返回视图中Entity的数量
+func (v *SortedView[T]) Len() int {
+	v.syncGroupKeys()
+	return len(v.positioned) + len(v.pending)
+}
+
+// At 返回排序后的第idx个Entity，idx的范围是[0, Len())
+func (v *SortedView[T]) At(idx int) Entity {
+	v.flush()
+	return v.order[idx]
+}
+
+// Entities 返回排序后的所有Entity的拷贝
+func (v *SortedView[T]) Entities() []Entity {
+	v.flush()
+	return slices.Clone(v.order)
+}
+
+// Foreach 按顺序遍历视图中的所有Entity，comp为Entity的T组件。
+// 遍历的是开始遍历时的顺序，遍历期间对视图的修改在下一次遍历时生效。
+// 注意，不要持有comp指针，参考TryGet的注释。
+func (v *SortedView[T]) Foreach(f func(entity Entity, comp *T)) {
+	v.flush()
+	order := v.order
+	for _, entity := range order {
+		// 遍历期间可能被移除或者销毁
+		if !v.positioned.Contains(entity) || !entity.IsAlive() {
+			continue
+		}
+		f(entity, Get[T](entity))
+	}
+}
+
+// ForeachReverse 与Foreach相同，但是按相反的顺序遍历
+func (v *SortedView[T]) ForeachReverse(f func(entity Entity, comp *T)) {
+	v.flush()
+	order := v.order
+	for i := len(order) - 1; i >= 0; i-- {
+		entity := order[i]
+		if !v.positioned.Contains(entity) || !entity.IsAlive() {
+			continue
+		}
+		f(entity, Get[T](entity))
+	}
+}
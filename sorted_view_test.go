@@ -0,0 +1,115 @@
+package ecs
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+type sortedTestZ struct {
+	Z int
+}
+
+func init() {
+	RegisterComponentType[sortedTestZ](16)
+}
+
+func newSortedTestView(zs ...int) (*World, *SortedView[sortedTestZ], []Entity) {
+	world := NewWorld()
+	filter := RegisterFilter(world, NewFilter1[sortedTestZ](world))
+	view := SortBy(filter, func(a, b *sortedTestZ) int { return cmp.Compare(a.Z, b.Z) })
+	entities := make([]Entity, len(zs))
+	for i, z := range zs {
+		entities[i] = world.NewEntity()
+		Replace(entities[i], sortedTestZ{Z: z})
+	}
+	return world, view, entities
+}
+
+func sortedViewZs(view *SortedView[sortedTestZ]) []int {
+	var zs []int
+	view.Foreach(func(entity Entity, comp *sortedTestZ) {
+		zs = append(zs, comp.Z)
+	})
+	return zs
+}
+
+func TestSortedViewOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		zs     []int
+		modify func(world *World, entities []Entity)
+		want   []int
+	}{
+		{name: "add", zs: []int{5, 1, 4, 2, 3}, want: []int{1, 2, 3, 4, 5}},
+		{name: "remove component", zs: []int{5, 1, 4}, modify: func(world *World, entities []Entity) {
+			Del[sortedTestZ](entities[0])
+		}, want: []int{1, 4}},
+		{name: "destroy", zs: []int{5, 1, 4}, modify: func(world *World, entities []Entity) {
+			entities[2].Destroy()
+		}, want: []int{1, 5}},
+		{name: "Replace", zs: []int{5, 1, 4}, modify: func(world *World, entities []Entity) {
+			Replace(entities[0], sortedTestZ{Z: 0})
+		}, want: []int{0, 1, 4}},
+		{name: "GetForWrite", zs: []int{5, 1, 4}, modify: func(world *World, entities []Entity) {
+			GetForWrite[sortedTestZ](entities[1]).Z = 9
+		}, want: []int{4, 5, 9}},
+		{name: "MarkDirty", zs: []int{5, 1, 4}, modify: func(world *World, entities []Entity) {
+			Get[sortedTestZ](entities[2]).Z = 6
+			MarkDirty[sortedTestZ](entities[2])
+		}, want: []int{1, 5, 6}},
+		{name: "add after flush", zs: []int{5, 1}, modify: func(world *World, entities []Entity) {
+			Replace(world.NewEntity(), sortedTestZ{Z: 3})
+		}, want: []int{1, 3, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, view, entities := newSortedTestView(tt.zs...)
+			sortedViewZs(view)
+			if tt.modify != nil {
+				tt.modify(world, entities)
+			}
+			if got := view.Len(); got != len(tt.want) {
+				t.Errorf("Len = %d, want %d", got, len(tt.want))
+			}
+			if got := sortedViewZs(view); !slices.Equal(got, tt.want) {
+				t.Errorf("Foreach = %v, want %v", got, tt.want)
+			}
+			var reverse []int
+			view.ForeachReverse(func(entity Entity, comp *sortedTestZ) {
+				reverse = append(reverse, comp.Z)
+			})
+			slices.Reverse(reverse)
+			if !slices.Equal(reverse, tt.want) {
+				t.Errorf("ForeachReverse = %v, want reversed %v", reverse, tt.want)
+			}
+			for i, z := range tt.want {
+				if got := Get[sortedTestZ](view.At(i)).Z; got != z {
+					t.Errorf("At(%d) = %d, want %d", i, got, z)
+				}
+			}
+		})
+	}
+}
+
+func TestSortedViewModifyDuringForeach(t *testing.T) {
+	_, view, entities := newSortedTestView(1, 2, 3, 4)
+	var visited []int
+	view.Foreach(func(entity Entity, comp *sortedTestZ) {
+		visited = append(visited, comp.Z)
+		switch comp.Z {
+		case 1:
+			// 移除尚未遍历到的Entity，本次遍历会跳过它
+			entities[2].Destroy()
+		case 2:
+			// 修改排序的key，下一次遍历时生效
+			GetForWrite[sortedTestZ](entity).Z = 10
+		}
+	})
+	if want := []int{1, 2, 4}; !slices.Equal(visited, want) {
+		t.Errorf("visited = %v, want %v", visited, want)
+	}
+	if got, want := sortedViewZs(view), []int{1, 4, 10}; !slices.Equal(got, want) {
+		t.Errorf("next Foreach = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,287 @@
+package ecs
+
+import (
+	"math"
+	"slices"
+)
+
+// 接口功能：将位置组件Pos转换为二维坐标
+type ISpatialMap[Pos any] interface {
+	MapPos(Pos) (x, y float64)
+}
+
+// 网格的坐标
+type gridCell struct {
+	x, y int
+}
+
+// entity在空间索引中的位置
+type spatialEntry struct {
+	x, y float64
+	cell gridCell
+}
+
+// SpatialIndex 基于均匀网格的二维空间索引，用于筛选持有Pos组件的Entity，
+// 并通过PosMapper将Pos转换为二维坐标，支持按圆形、矩形范围查询以及查询最近的k个Entity。
+// 与GroupFilter一样，Entity的位置通过Replace或写入路径（GetForWrite、MarkDirty等）修改时，索引会自动更新。
+// 网格的大小（cellSize）最好与常用的查询半径相近。坐标不是有限值（NaN、Inf）的Entity不会被加入索引。
+// 注意：其依赖于Filter1[Pos]，要先注册Filter1[Pos]；PosMapper必须是struct类型！！
+type SpatialIndex[Pos any, PosMapper ISpatialMap[Pos]] struct {
+	groupKeySyncer
+	mapper   PosMapper
+	cellSize float64
+	cells    map[gridCell]Set[Entity]
+	// 每个Entity加入时的位置，用于准确地移除Entity
+	entries map[Entity]spatialEntry
+}
+
+func NewSpatialIndex[Pos any, PosMapper ISpatialMap[Pos]](world *World, cellSize float64) *SpatialIndex[Pos, PosMapper] {
+	if cellSize <= 0 {
+		panic("cellSize must be positive")
+	}
+	filter := GetFilter[*Filter1[Pos]](world)
+	si := &SpatialIndex[Pos, PosMapper]{
+		groupKeySyncer: groupKeySyncer{world: world},
+		cellSize:       cellSize,
+		cells:          make(map[gridCell]Set[Entity]),
+		entries:        make(map[Entity]spatialEntry),
+	}
+	//监听filter的Entity增删事件，使用内部优先级，保证用户的监听被调用时索引已经更新
+	filter.AddListenerWithPriority(si, PriorityInternal)
+	//Pos的变化需要更新索引，监听其增删
+	registerGroupKeyEventByType[Pos](world, si, filter)
+	return si
+}
+
+func (si *SpatialIndex[Pos, PosMapper]) iamGroupFilter() {}
+
+// 网格坐标的范围，超出范围的坐标归入边界上的网格，避免转换为int时溢出
+const maxGridCoord = 1 << 30
+
+// 坐标所在的网格
+func (si *SpatialIndex[Pos, PosMapper]) cellOf(x, y float64) gridCell {
+	return gridCell{
+		x: gridCoord(x / si.cellSize),
+		y: gridCoord(y / si.cellSize),
+	}
+}
+
+func gridCoord(v float64) int {
+	return int(max(-maxGridCoord, min(maxGridCoord, math.Floor(v))))
+}
+
+// 坐标是否是有限值，NaN、Inf不是
+func isFinitePos(x, y float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0) && !math.IsNaN(y) && !math.IsInf(y, 0)
+}
+
+// 实现FilterEventListener接口。
+// 坐标不是有限值（NaN、Inf）的Entity不会被加入索引，直到其坐标变为有限值。
+func (si *SpatialIndex[Pos, PosMapper]) OnEntityAdded(entity Entity) {
+	x, y := si.mapper.MapPos(*Get[Pos](entity))
+	if !isFinitePos(x, y) {
+		si.OnEntityRemoved(entity)
+		return
+	}
+	cell := si.cellOf(x, y)
+	if old, ok := si.entries[entity]; ok {
+		if old.cell == cell {
+			si.entries[entity] = spatialEntry{x: x, y: y, cell: cell}
+			return
+		}
+		si.OnEntityRemoved(entity)
+	}
+	set, ok := si.cells[cell]
+	if !ok {
+		set = make(Set[Entity])
+		si.cells[cell] = set
+	}
+	set.Add(entity)
+	si.entries[entity] = spatialEntry{x: x, y: y, cell: cell}
+}
+
+// 实现FilterEventListener接口，使用加入时记录的位置移除
+func (si *SpatialIndex[Pos, PosMapper]) OnEntityRemoved(entity Entity) {
+	entry, ok := si.entries[entity]
+	if !ok {
+		return
+	}
+	delete(si.entries, entity)
+	set := si.cells[entry.cell]
+	delete(set, entity)
+	// 回收空的网格
+	if len(set) == 0 {
+		delete(si.cells, entry.cell)
+	}
+}
+
+// 实现iEntitySet接口
+func (si *SpatialIndex[Pos, PosMapper]) Add(entity Entity) {
+	si.OnEntityAdded(entity)
+}
+
+// 实现iEntitySet接口
+func (si *SpatialIndex[Pos, PosMapper]) Remove(entity Entity) {
+	si.OnEntityRemoved(entity)
+}
+
+// Len 返回索引中Entity的数量
+func (si *SpatialIndex[Pos, PosMapper]) Len() int {
+	si.syncGroupKeys()
+	return len(si.entries)
+}
+
+// PositionOf 返回Entity在索引中的坐标，Entity不在索引中时返回false
+func (si *SpatialIndex[Pos, PosMapper]) PositionOf(entity Entity) (x, y float64, ok bool) {
+	si.syncGroupKeys()
+	entry, ok := si.entries[entity]
+	return entry.x, entry.y, ok
+}
+
+// 遍历与[minCell, maxCell]相交的所有网格中的Entity，f返回false时停止遍历并返回false
+func (si *SpatialIndex[Pos, PosMapper]) foreachInCells(minCell, maxCell gridCell, f func(entity Entity, entry spatialEntry) bool) bool {
+	visit := func(set Set[Entity]) bool {
+		for e := range set {
+			if !f(e, si.entries[e]) {
+				return false
+			}
+		}
+		return true
+	}
+	// 范围内的网格数量比非空的网格还多时，直接遍历非空的网格
+	width, height := maxCell.x-minCell.x+1, maxCell.y-minCell.y+1
+	if width > len(si.cells) || height > len(si.cells) || width*height > len(si.cells) {
+		for cell, set := range si.cells {
+			if cell.x >= minCell.x && cell.x <= maxCell.x && cell.y >= minCell.y && cell.y <= maxCell.y {
+				if !visit(set) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for cx := minCell.x; cx <= maxCell.x; cx++ {
+		for cy := minCell.y; cy <= maxCell.y; cy++ {
+			if set, ok := si.cells[gridCell{x: cx, y: cy}]; ok {
+				if !visit(set) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// QueryRadius 遍历与(x, y)的距离不超过r的所有Entity，f返回false时停止遍历，遍历顺序是不确定的。
+// 注意，遍历期间不要修改Entity的位置或者增删Entity。
+func (si *SpatialIndex[Pos, PosMapper]) QueryRadius(x, y, r float64, f func(entity Entity) bool) {
+	si.syncGroupKeys()
+	if math.IsNaN(x) || math.IsNaN(y) || math.IsNaN(r) {
+		return
+	}
+	r2 := r * r
+	si.foreachInCells(si.cellOf(x-r, y-r), si.cellOf(x+r, y+r), func(entity Entity, entry spatialEntry) bool {
+		dx, dy := entry.x-x, entry.y-y
+		if dx*dx+dy*dy > r2 {
+			return true
+		}
+		return f(entity)
+	})
+}
+
+// QueryAABB 遍历坐标在矩形[minX, maxX]×[minY, maxY]（包含边界）中的所有Entity，
+// f返回false时停止遍历，遍历顺序是不确定的。
+// 注意，遍历期间不要修改Entity的位置或者增删Entity。
+func (si *SpatialIndex[Pos, PosMapper]) QueryAABB(minX, minY, maxX, maxY float64, f func(entity Entity) bool) {
+	si.syncGroupKeys()
+	if math.IsNaN(minX) || math.IsNaN(minY) || math.IsNaN(maxX) || math.IsNaN(maxY) {
+		return
+	}
+	si.foreachInCells(si.cellOf(minX, minY), si.cellOf(maxX, maxY), func(entity Entity, entry spatialEntry) bool {
+		if entry.x < minX || entry.x > maxX || entry.y < minY || entry.y > maxY {
+			return true
+		}
+		return f(entity)
+	})
+}
+
+// Nearest 返回与(x, y)最近的k个Entity，按距离从近到远排列，Entity不足k个时返回所有的Entity。
+// (x, y)不是有限值时返回 nil。
+func (si *SpatialIndex[Pos, PosMapper]) Nearest(x, y float64, k int) []Entity {
+	si.syncGroupKeys()
+	if k <= 0 || len(si.entries) == 0 || !isFinitePos(x, y) {
+		return nil
+	}
+
+	type candidate struct {
+		entity Entity
+		dist2  float64
+	}
+	var candidates []candidate
+	collect := func(entity Entity, entry spatialEntry) bool {
+		dx, dy := entry.x-x, entry.y-y
+		candidates = append(candidates, candidate{entity: entity, dist2: dx*dx + dy*dy})
+		return true
+	}
+	byDist := func(a, b candidate) int {
+		if a.dist2 < b.dist2 {
+			return -1
+		} else if a.dist2 > b.dist2 {
+			return 1
+		}
+		return 0
+	}
+
+	// 以(x, y)所在的网格为中心，一圈一圈地向外搜索，最多搜索到最远的非空网格所在的圈
+	center := si.cellOf(x, y)
+	maxRing := 0
+	for cell := range si.cells {
+		maxRing = max(maxRing, absInt(cell.x-center.x), absInt(cell.y-center.y))
+	}
+	for ring := 0; ring <= maxRing; ring++ {
+		// 已搜索的网格比非空的网格还多时（比如Entity离(x, y)很远），继续逐圈搜索不如直接遍历所有的Entity
+		if ring*ring > len(si.cells) {
+			candidates = candidates[:0]
+			for entity, entry := range si.entries {
+				collect(entity, entry)
+			}
+			break
+		}
+		if ring == 0 {
+			si.foreachInCells(center, center, collect)
+		} else {
+			// 第ring圈的上下两行和左右两列（不含角）
+			lo, hi := center.y-ring, center.y+ring
+			si.foreachInCells(gridCell{center.x - ring, lo}, gridCell{center.x + ring, lo}, collect)
+			si.foreachInCells(gridCell{center.x - ring, hi}, gridCell{center.x + ring, hi}, collect)
+			si.foreachInCells(gridCell{center.x - ring, lo + 1}, gridCell{center.x - ring, hi - 1}, collect)
+			si.foreachInCells(gridCell{center.x + ring, lo + 1}, gridCell{center.x + ring, hi - 1}, collect)
+		}
+		if len(candidates) == len(si.entries) {
+			break
+		}
+		if len(candidates) >= k {
+			// 第ring圈之外的Entity，与(x, y)的距离至少为 ring*cellSize
+			slices.SortFunc(candidates, byDist)
+			bound := float64(ring) * si.cellSize
+			if candidates[k-1].dist2 <= bound*bound {
+				break
+			}
+		}
+	}
+
+	slices.SortFunc(candidates, byDist)
+	n := min(k, len(candidates))
+	result := make([]Entity, n)
+	for i := range n {
+		result[i] = candidates[i].entity
+	}
+	return result
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
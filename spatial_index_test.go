@@ -0,0 +1,160 @@
+package ecs
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+type spatialTestPos struct {
+	X, Y float64
+}
+
+type spatialTestPosMapper struct{}
+
+func (spatialTestPosMapper) MapPos(p spatialTestPos) (float64, float64) {
+	return p.X, p.Y
+}
+
+func init() {
+	RegisterComponentType[spatialTestPos](16)
+}
+
+func newSpatialTestIndex(cellSize float64, positions ...spatialTestPos) (*SpatialIndex[spatialTestPos, spatialTestPosMapper], []Entity) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[spatialTestPos](world))
+	si := NewSpatialIndex[spatialTestPos, spatialTestPosMapper](world, cellSize)
+	entities := make([]Entity, len(positions))
+	for i, pos := range positions {
+		entities[i] = world.NewEntity()
+		Replace(entities[i], pos)
+	}
+	return si, entities
+}
+
+// 暴力计算与(x, y)最近的k个Entity的距离
+func bruteForceNearestDist(positions []spatialTestPos, x, y float64, k int) []float64 {
+	dists := make([]float64, 0, len(positions))
+	for _, p := range positions {
+		dists = append(dists, math.Hypot(p.X-x, p.Y-y))
+	}
+	slices.Sort(dists)
+	return dists[:min(k, len(dists))]
+}
+
+func TestSpatialIndexNearest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]spatialTestPos, 200)
+	for i := range random {
+		random[i] = spatialTestPos{X: rng.Float64()*200 - 100, Y: rng.Float64()*200 - 100}
+	}
+	tests := []struct {
+		name      string
+		cellSize  float64
+		positions []spatialTestPos
+		x, y      float64
+		k         int
+	}{
+		{name: "random small k", cellSize: 10, positions: random, x: 3, y: -7, k: 5},
+		{name: "random k larger than count", cellSize: 10, positions: random, x: 0, y: 0, k: 500},
+		{name: "query outside", cellSize: 10, positions: random, x: 1000, y: 1000, k: 3},
+		{name: "tiny cells", cellSize: 0.5, positions: random, x: 50, y: 50, k: 10},
+		{name: "far away entity", cellSize: 1, positions: []spatialTestPos{{X: 1e8, Y: 0}}, x: 0, y: 0, k: 2},
+		{name: "near and far", cellSize: 1, positions: []spatialTestPos{{X: 1, Y: 1}, {X: -1e12, Y: 1e12}, {X: 2, Y: 2}}, x: 0, y: 0, k: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			si, _ := newSpatialTestIndex(tt.cellSize, tt.positions...)
+			start := time.Now()
+			result := si.Nearest(tt.x, tt.y, tt.k)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Nearest took %v", elapsed)
+			}
+			want := bruteForceNearestDist(tt.positions, tt.x, tt.y, tt.k)
+			if len(result) != len(want) {
+				t.Fatalf("len = %d, want %d", len(result), len(want))
+			}
+			for i, entity := range result {
+				pos := Get[spatialTestPos](entity)
+				if got := math.Hypot(pos.X-tt.x, pos.Y-tt.y); got != want[i] {
+					t.Errorf("result[%d] dist = %v, want %v", i, got, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSpatialIndexNonFinite(t *testing.T) {
+	tests := []struct {
+		name string
+		pos  spatialTestPos
+	}{
+		{name: "+Inf", pos: spatialTestPos{X: math.Inf(1), Y: 0}},
+		{name: "-Inf", pos: spatialTestPos{X: 0, Y: math.Inf(-1)}},
+		{name: "NaN", pos: spatialTestPos{X: math.NaN(), Y: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			si, entities := newSpatialTestIndex(1, spatialTestPos{X: 1, Y: 1}, tt.pos)
+			if got := si.Len(); got != 1 {
+				t.Errorf("Len = %d, want 1", got)
+			}
+			if got := si.Nearest(0, 0, 2); len(got) != 1 || got[0] != entities[0] {
+				t.Errorf("Nearest = %v, want [%v]", got, entities[0])
+			}
+			// 坐标变为有限值后加入索引
+			GetForWrite[spatialTestPos](entities[1]).X = 5
+			GetForWrite[spatialTestPos](entities[1]).Y = 5
+			if got := si.Len(); got != 2 {
+				t.Errorf("Len after fix = %d, want 2", got)
+			}
+			// 坐标再次变为非有限值后从索引中移除
+			Replace(entities[1], tt.pos)
+			if _, _, ok := si.PositionOf(entities[1]); ok {
+				t.Errorf("entity with non-finite position is indexed")
+			}
+			if got := si.Nearest(math.NaN(), 0, 1); got != nil {
+				t.Errorf("Nearest(NaN) = %v, want nil", got)
+			}
+		})
+	}
+}
+
+func TestSpatialIndexQueries(t *testing.T) {
+	positions := []spatialTestPos{{X: 0, Y: 0}, {X: 3, Y: 4}, {X: -3, Y: -4}, {X: 10, Y: 0}, {X: 2.5, Y: 2.5}}
+	si, entities := newSpatialTestIndex(2, positions...)
+	collect := func(query func(f func(entity Entity) bool)) []Entity {
+		var result []Entity
+		query(func(entity Entity) bool {
+			result = append(result, entity)
+			return true
+		})
+		slices.SortFunc(result, func(a, b Entity) int { return a.Id - b.Id })
+		return result
+	}
+	tests := []struct {
+		name  string
+		query func(f func(entity Entity) bool)
+		want  []Entity
+	}{
+		{name: "radius 5", query: func(f func(Entity) bool) { si.QueryRadius(0, 0, 5, f) },
+			want: []Entity{entities[0], entities[1], entities[2], entities[4]}},
+		{name: "radius 1", query: func(f func(Entity) bool) { si.QueryRadius(0, 0, 1, f) },
+			want: []Entity{entities[0]}},
+		{name: "radius inf", query: func(f func(Entity) bool) { si.QueryRadius(0, 0, math.Inf(1), f) },
+			want: entities},
+		{name: "aabb", query: func(f func(Entity) bool) { si.QueryAABB(0, 0, 3, 4, f) },
+			want: []Entity{entities[0], entities[1], entities[4]}},
+		{name: "aabb empty", query: func(f func(Entity) bool) { si.QueryAABB(20, 20, 30, 30, f) },
+			want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collect(tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
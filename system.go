@@ -1,5 +1,71 @@
 package ecs
 
+import (
+	"reflect"
+	"slices"
+)
+
 type Systemer interface {
 	Update()
 }
+
+// ResourceAccess 系统对资源的读写声明，调度器可以据此检测系统之间的读写冲突：
+// 一个系统写入的资源被另一个系统读取或写入时，两个系统不能并行执行。
+// 资源类型通过ResourceType获取，比如：
+//
+//	new(ecs.ResourceAccess).Read(ecs.ResourceType[Time]()).Write(ecs.ResourceType[Score]())
+type ResourceAccess struct {
+	Reads  []reflect.Type
+	Writes []reflect.Type
+}
+
+// Read 声明读取types中的资源，返回a自身，以便链式调用
+func (a *ResourceAccess) Read(types ...reflect.Type) *ResourceAccess {
+	a.Reads = append(a.Reads, types...)
+	return a
+}
+
+// Write 声明写入types中的资源（写入包含读取），返回a自身，以便链式调用
+func (a *ResourceAccess) Write(types ...reflect.Type) *ResourceAccess {
+	a.Writes = append(a.Writes, types...)
+	return a
+}
+
+// Conflicts 判断两个读写声明是否冲突，即一方写入的资源被另一方读取或写入。
+// nil表示不读写任何资源，与任何声明都不冲突。
+func (a *ResourceAccess) Conflicts(b *ResourceAccess) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	for _, t := range a.Writes {
+		if slices.Contains(b.Reads, t) || slices.Contains(b.Writes, t) {
+			return true
+		}
+	}
+	for _, t := range b.Writes {
+		if slices.Contains(a.Reads, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceAccessor 系统可以实现该接口，声明其对资源的读写，参考ResourceAccess。
+// ResourceAccess返回 nil 表示系统不读写任何资源。
+type ResourceAccessor interface {
+	ResourceAccess() *ResourceAccess
+}
+
+// SystemsConflict 判断两个系统对资源的读写是否冲突，调度器可以据此决定两个系统能否并行执行。
+// 没有实现ResourceAccessor的系统被视为可能读写任意资源，与任何系统都冲突。
+func SystemsConflict(a, b Systemer) bool {
+	accessorA, ok := a.(ResourceAccessor)
+	if !ok {
+		return true
+	}
+	accessorB, ok := b.(ResourceAccessor)
+	if !ok {
+		return true
+	}
+	return accessorA.ResourceAccess().Conflicts(accessorB.ResourceAccess())
+}
@@ -0,0 +1,256 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// UniqueConflictPolicy 唯一索引出现key冲突（两个entity的key相同）时的处理策略。
+// 无论哪种策略，发生冲突时都保留已有的entity，新的entity不会被加入索引，
+// 直到它的key变为一个没有被占用的值，或者占用该key的entity离开了索引（被销毁、key被修改等），
+// 此时由最早因为该key冲突、并且仍然持有该key的entity补上。
+type UniqueConflictPolicy int
+
+const (
+	// UniqueConflictPanic 发生冲突时panic，适用于key一定唯一、冲突即为bug的场景
+	UniqueConflictPanic UniqueConflictPolicy = iota
+	// UniqueConflictCallback 发生冲突时调用通过OnConflict注册的回调
+	UniqueConflictCallback
+	// UniqueConflictError 发生冲突时记录一个*UniqueKeyConflictError，可以通过Err获取
+	UniqueConflictError
+)
+
+// UniqueKeyConflictError 唯一索引的key冲突错误
+type UniqueKeyConflictError struct {
+	Key any
+	// 已经在索引中的entity
+	Existing Entity
+	// 因为冲突而没有加入索引的entity
+	Incoming Entity
+}
+
+func (e *UniqueKeyConflictError) Error() string {
+	return fmt.Sprintf("unique key conflict: key:%+v, existing entity:%+v, incoming entity:%+v", e.Key, e.Existing, e.Incoming)
+}
+
+// uniqueGroupFilterBase 与groupFilterBase类似，但是要求每个key最多只对应一个Entity，
+// 内部直接存储 map[Key]Entity，没有Set的开销。
+// 当两个Entity的key相同时，按照UniqueConflictPolicy处理冲突。
+type uniqueGroupFilterBase[Key comparable, KeyMaker groupKeyMaker[Key]] struct {
+	groupKeySyncer
+	keyMaker KeyMaker
+	entities map[Key]Entity
+	// 已加入索引的Entity的key，用于准确地移除Entity，因为冲突而没有加入索引的Entity不在其中
+	keyOf map[Entity]Key
+	// 因为冲突而没有加入索引的Entity，按冲突发生的顺序排列
+	rejected map[Key][]Entity
+	// 因为冲突而没有加入索引的Entity的key
+	rejectedKeyOf map[Entity]Key
+	// 原来的Entity离开了索引、等待由rejected中的Entity补上的key。
+	// 不立即补上，是因为通过写入路径修改key时，原来的Entity会先离开索引，之后可能以相同的key重新加入
+	vacated Set[Key]
+	policy  UniqueConflictPolicy
+	// 冲突回调，仅在UniqueConflictCallback策略下使用
+	conflictCallbacks callbackList[func(key Key, existing, incoming Entity)]
+	// 记录的冲突错误，仅在UniqueConflictError策略下使用
+	errs []error
+}
+
+func newUniqueGroupFilterBase[Key comparable, KeyMaker groupKeyMaker[Key]](filter IFilter, policy UniqueConflictPolicy) *uniqueGroupFilterBase[Key, KeyMaker] {
+	gf := &uniqueGroupFilterBase[Key, KeyMaker]{
+		groupKeySyncer: groupKeySyncer{world: filter.getWorld()},
+		entities:       make(map[Key]Entity),
+		keyOf:          make(map[Entity]Key),
+		rejected:       make(map[Key][]Entity),
+		rejectedKeyOf:  make(map[Entity]Key),
+		vacated:        make(Set[Key]),
+		policy:         policy,
+	}
+	//监听filter的Entity增删事件，使用内部优先级，保证用户的监听被调用时索引已经更新
+	filter.AddListenerWithPriority(gf, PriorityInternal)
+	return gf
+}
+
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) iamGroupFilter() {}
+
+// 实现FilterEventListener接口
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) OnEntityAdded(entity Entity) {
+	key := gf.keyMaker.makeKey(entity)
+	existing, ok := gf.entities[key]
+	if ok && existing == entity {
+		return
+	}
+	// 已经以其他的key加入了索引（或者因为冲突在等待），先移除
+	gf.OnEntityRemoved(entity)
+	if ok {
+		gf.rejected[key] = append(gf.rejected[key], entity)
+		gf.rejectedKeyOf[entity] = key
+		gf.conflict(key, existing, entity)
+		return
+	}
+	gf.entities[key] = entity
+	gf.keyOf[entity] = key
+}
+
+// 实现FilterEventListener接口，使用加入时记录的key移除
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) OnEntityRemoved(entity Entity) {
+	if key, ok := gf.rejectedKeyOf[entity]; ok {
+		delete(gf.rejectedKeyOf, entity)
+		gf.removeRejected(key, entity)
+		return
+	}
+	key, ok := gf.keyOf[entity]
+	if !ok {
+		return
+	}
+	delete(gf.keyOf, entity)
+	delete(gf.entities, key)
+	if len(gf.rejected[key]) > 0 {
+		gf.vacated.Add(key)
+	}
+}
+
+// 从key的等待队列中移除entity
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) removeRejected(key Key, entity Entity) {
+	queue := gf.rejected[key]
+	for i, e := range queue {
+		if e == entity {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) == 0 {
+		delete(gf.rejected, key)
+		return
+	}
+	gf.rejected[key] = queue
+}
+
+// 同步key组件的写入，然后由等待中的Entity补上空出来的key
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) sync() {
+	gf.syncGroupKeys()
+	for key := range gf.vacated {
+		delete(gf.vacated, key)
+		queue := gf.rejected[key]
+		// 原来的Entity以相同的key重新加入了，此时它的key仍然被占用
+		if _, ok := gf.entities[key]; ok || len(queue) == 0 {
+			continue
+		}
+		entity := queue[0]
+		gf.removeRejected(key, entity)
+		delete(gf.rejectedKeyOf, entity)
+		gf.entities[key] = entity
+		gf.keyOf[entity] = key
+	}
+}
+
+// 实现iEntitySet接口
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) Add(entity Entity) {
+	gf.OnEntityAdded(entity)
+}
+
+// 实现iEntitySet接口
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) Remove(entity Entity) {
+	gf.OnEntityRemoved(entity)
+}
+
+// 按照策略处理冲突
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) conflict(key Key, existing, incoming Entity) {
+	switch gf.policy {
+	case UniqueConflictCallback:
+		gf.conflictCallbacks.foreach(func(cb func(key Key, existing, incoming Entity)) {
+			cb(key, existing, incoming)
+		})
+	case UniqueConflictError:
+		gf.errs = append(gf.errs, &UniqueKeyConflictError{Key: key, Existing: existing, Incoming: incoming})
+	default:
+		panic(&UniqueKeyConflictError{Key: key, Existing: existing, Incoming: incoming})
+	}
+}
+
+// Get 获取key对应的Entity
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) Get(key Key) (Entity, bool) {
+	gf.sync()
+	e, ok := gf.entities[key]
+	return e, ok
+}
+
+// KeyOf 返回Entity在索引中的key，Entity不在索引中（包括因为冲突没有加入索引）时返回false
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) KeyOf(entity Entity) (Key, bool) {
+	gf.sync()
+	key, ok := gf.keyOf[entity]
+	return key, ok
+}
+
+// FindOne 与Get相同，与groupFilterBase保持一致的接口
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) FindOne(key Key) (Entity, bool) {
+	return gf.Get(key)
+}
+
+// Keys 返回所有的key，顺序是不确定的
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) Keys() []Key {
+	gf.sync()
+	keys := make([]Key, 0, len(gf.entities))
+	for key := range gf.entities {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Len 返回索引中Entity（不同的key）的数量
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) Len() int {
+	gf.sync()
+	return len(gf.entities)
+}
+
+// OnConflict 注册冲突回调，仅在UniqueConflictCallback策略下会被调用。
+// existing为已经在索引中的entity，incoming为因为冲突而没有加入索引的entity。
+// 返回的Subscription用于取消注册。
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) OnConflict(cb func(key Key, existing, incoming Entity)) Subscription {
+	return gf.conflictCallbacks.add(cb)
+}
+
+// Err 返回UniqueConflictError策略下记录的所有冲突错误（errors.Join），没有冲突时返回 nil。
+// 可以通过errors.As获取其中的*UniqueKeyConflictError。
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) Err() error {
+	gf.sync()
+	return errors.Join(gf.errs...)
+}
+
+// ClearErr 清除记录的冲突错误
+func (gf *uniqueGroupFilterBase[Key, KeyMaker]) ClearErr() {
+	gf.errs = nil
+}
+
+// UniqueGroupFilter[KeyComp comparable] 与GroupFilter[KeyComp]类似，但是key是唯一的，
+// 即一个key最多只对应一个Entity，比如身份证号、玩家id等。
+// 注意：其依赖于Filter1[KeyComp]，要先注册Filter1[KeyComp]。
+type UniqueGroupFilter[KeyComp comparable] struct {
+	*uniqueGroupFilterBase[KeyComp, DirectlyKeyMaker[KeyComp]]
+}
+
+func NewUniqueGroupFilter[KeyComp comparable](world *World, policy UniqueConflictPolicy) *UniqueGroupFilter[KeyComp] {
+	filter := GetFilter[*Filter1[KeyComp]](world)
+	gf := &UniqueGroupFilter[KeyComp]{
+		newUniqueGroupFilterBase[KeyComp, DirectlyKeyMaker[KeyComp]](filter, policy),
+	}
+	//KeyComp是groupKey，监听其增删
+	registerGroupKeyEventByType[KeyComp](world, gf, filter)
+	return gf
+}
+
+// 与UniqueGroupFilter类似，但是通过键值转换器KeyMapper将KeyComp转换为Key。
+// 注意：KeyMapper必须是struct类型！！
+type UniqueGroupFilterWithKeyMapper[KeyComp any, Key comparable, KeyMapper IGroupKeyMap[KeyComp, Key]] struct {
+	*uniqueGroupFilterBase[Key, groupKeyMapper[KeyComp, Key, KeyMapper]]
+}
+
+func NewUniqueGroupFilterWithKeyMapper[KeyComp any, Key comparable, KeyMapper IGroupKeyMap[KeyComp, Key]](world *World, policy UniqueConflictPolicy) *UniqueGroupFilterWithKeyMapper[KeyComp, Key, KeyMapper] {
+	filter := GetFilter[*Filter1[KeyComp]](world)
+	gf := &UniqueGroupFilterWithKeyMapper[KeyComp, Key, KeyMapper]{
+		newUniqueGroupFilterBase[Key, groupKeyMapper[KeyComp, Key, KeyMapper]](filter, policy),
+	}
+	//KeyComp是groupKey，监听其增删
+	registerGroupKeyEventByType[KeyComp](world, gf, filter)
+	return gf
+}
@@ -0,0 +1,109 @@
+package ecs
+
+import (
+	"errors"
+	"testing"
+)
+
+type uniqueTestId struct {
+	V int
+}
+
+func init() {
+	RegisterComponentType[uniqueTestId](16)
+}
+
+func TestUniqueGroupFilterConflict(t *testing.T) {
+	type step func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity)
+	tests := []struct {
+		name   string
+		policy UniqueConflictPolicy
+		change step
+		// change之后key 1对应的Entity，0表示e1，1表示e2，-1表示没有
+		owner int
+		// 冲突的次数（包括初始的一次）
+		conflicts int
+	}{
+		{name: "keep existing", policy: UniqueConflictError,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) {}, owner: 0, conflicts: 1},
+		{name: "destroy existing", policy: UniqueConflictError,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) { e1.Destroy() }, owner: 1, conflicts: 1},
+		{name: "destroy existing deferred", policy: UniqueConflictCallback,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) {
+				e1.DestroyDeferred()
+				e1.World().FlushDestroyed()
+			}, owner: 1, conflicts: 1},
+		{name: "del existing key", policy: UniqueConflictCallback,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) { Del[uniqueTestId](e1) }, owner: 1, conflicts: 1},
+		{name: "replace existing key", policy: UniqueConflictError,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) { Replace(e1, uniqueTestId{V: 9}) }, owner: 1, conflicts: 1},
+		{name: "write existing key", policy: UniqueConflictError,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) { GetForWrite[uniqueTestId](e1).V = 9 }, owner: 1, conflicts: 1},
+		{name: "write existing without changing key", policy: UniqueConflictError,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) { MarkDirty[uniqueTestId](e1) }, owner: 0, conflicts: 1},
+		{name: "incoming moves to free key", policy: UniqueConflictError,
+			change: func(gf *UniqueGroupFilter[uniqueTestId], e1, e2 Entity) {
+				Replace(e2, uniqueTestId{V: 2})
+				e1.Destroy()
+			}, owner: -1, conflicts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			RegisterFilter(world, NewFilter1[uniqueTestId](world))
+			gf := NewUniqueGroupFilter[uniqueTestId](world, tt.policy)
+			conflicts := 0
+			gf.OnConflict(func(key uniqueTestId, existing, incoming Entity) {
+				conflicts++
+			})
+			e1 := world.NewEntity()
+			Replace(e1, uniqueTestId{V: 1})
+			e2 := world.NewEntity()
+			Replace(e2, uniqueTestId{V: 1})
+
+			tt.change(gf, e1, e2)
+
+			got, ok := gf.Get(uniqueTestId{V: 1})
+			switch tt.owner {
+			case -1:
+				if ok {
+					t.Errorf("Get = %v, want none", got)
+				}
+			default:
+				want := []Entity{e1, e2}[tt.owner]
+				if !ok || got != want {
+					t.Errorf("Get = %v, %v, want %v, true", got, ok, want)
+				}
+				if key, ok := gf.KeyOf(want); !ok || key.V != 1 {
+					t.Errorf("KeyOf = %v, %v, want {1}, true", key, ok)
+				}
+			}
+			if tt.policy == UniqueConflictError {
+				conflicts = len(gf.errs)
+				var conflictErr *UniqueKeyConflictError
+				if !errors.As(gf.Err(), &conflictErr) || conflictErr.Existing != e1 || conflictErr.Incoming != e2 {
+					t.Errorf("Err = %v, want conflict between %v and %v", gf.Err(), e1, e2)
+				}
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("conflicts = %d, want %d", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestUniqueGroupFilterConflictPanic(t *testing.T) {
+	world := NewWorld()
+	RegisterFilter(world, NewFilter1[uniqueTestId](world))
+	NewUniqueGroupFilter[uniqueTestId](world, UniqueConflictPanic)
+	e1 := world.NewEntity()
+	Replace(e1, uniqueTestId{V: 1})
+	e2 := world.NewEntity()
+	defer func() {
+		err, ok := recover().(*UniqueKeyConflictError)
+		if !ok || err.Existing != e1 || err.Incoming != e2 {
+			t.Errorf("recover = %v, want conflict between %v and %v", err, e1, e2)
+		}
+	}()
+	Replace(e2, uniqueTestId{V: 1})
+}
@@ -2,8 +2,9 @@ package ecs
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
-	"unsafe"
+	"slices"
 
 	dataPool "github.com/Lei2050/array-pool"
 )
@@ -34,6 +35,25 @@ type World struct {
 	// groupKeyEventReceivers 管理所有groupKey事件的接收者
 	// 用于通知groupFilter过滤器，当entity的groupKey发生变化时，需要更新集合
 	groupKeyEventReceivers map[int][]groupKeyEvent //<typeIndex, []handler> //key是comp的typeIndex
+	// pendingGroupKeys 通过写入路径（GetForWrite、MarkDirty等）修改了key组件、
+	// 已从groupFilter中移除、等待按新的key重新加入的entity，键为key组件的类型索引，参考SyncGroupKeys
+	pendingGroupKeys map[int][]Entity //<typeIndex, []entity>
+	// pendingGroupKeySet 用于pendingGroupKeys去重
+	pendingGroupKeySet map[pendingGroupKey]struct{}
+	// relations 管理所有entity之间的关系，键为关系类型索引
+	relations map[int]*relationStore //<relationTypeIndex, store>
+	// Events 与entity生命周期相关的事件，外部可以通过它来监听entity的创建和销毁
+	Events WorldEvents
+	// destroyQueue 延迟销毁的entity队列，在FlushDestroyed时统一销毁
+	destroyQueue []Entity
+	// deferredDestroyMode 延迟销毁的entity从过滤器中移除的时机
+	deferredDestroyMode DeferredDestroyMode
+	// eventBuses 自定义事件的事件总线，键为事件的反射类型
+	eventBuses map[reflect.Type]iEventBus //<Type, *eventBus[T]>
+	// componentHooks 类型化的组件钩子（OnAdd/OnRemove/OnReplace），键为组件类型索引
+	componentHooks map[int]iComponentHooks //<typeIndex, *componentHooks[T]>
+	// resources World级别的全局资源，键为资源的反射类型
+	resources map[reflect.Type]any //<Type, *T>
 }
 
 // 实列化一个World
@@ -50,11 +70,43 @@ func NewWorld() *World {
 		filterByExcludedComps: make(map[int][]IFilter),
 
 		groupKeyEventReceivers: make(map[int][]groupKeyEvent),
+		pendingGroupKeys:       make(map[int][]Entity),
+		pendingGroupKeySet:     make(map[pendingGroupKey]struct{}),
+
+		relations: make(map[int]*relationStore),
+
+		eventBuses:     make(map[reflect.Type]iEventBus),
+		componentHooks: make(map[int]iComponentHooks),
+
+		resources: make(map[reflect.Type]any),
 	}
 }
 
 // 在当前世界中创建一个新的实体。
 func (w *World) NewEntity() Entity {
+	entity := w.newEntity()
+	w.Events.EntityCreated.Invoke(entity)
+	return entity
+}
+
+// 监听entity创建事件，参考WorldEvents.EntityCreated
+func (w *World) OnEntityCreated(cb func(entity Entity)) Subscription {
+	return w.Events.EntityCreated.AddCallback(cb)
+}
+
+// 监听entity开始销毁事件，参考WorldEvents.EntityDestroying
+func (w *World) OnEntityDestroying(cb func(entity Entity)) Subscription {
+	return w.Events.EntityDestroying.AddCallback(cb)
+}
+
+// 监听entity销毁完成事件，参考WorldEvents.EntityDestroyed
+func (w *World) OnEntityDestroyed(cb func(entity Entity)) Subscription {
+	return w.Events.EntityDestroyed.AddCallback(cb)
+}
+
+// newEntity 创建一个新的实体，但不触发entity创建事件，
+// 用于需要在添加完组件后才触发创建事件的场景。
+func (w *World) newEntity() Entity {
 	// 从实体池中分配一个新的实体数据，返回该实体在池中的索引 idx 和指向实体数据的指针 pe
 	idx, pe := w.entityPool.Alloc()
 	if pe.Gen == 0 {
@@ -64,8 +116,35 @@ func (w *World) NewEntity() Entity {
 	return Entity{
 		Id:       idx,
 		Gen:      pe.Gen,
-		WorldPtr: uintptr(unsafe.Pointer(w)),
+		WorldPtr: w,
+	}
+}
+
+// SpawnBatch 批量创建count个拥有相同组件的实体。
+// components中的每个元素都必须是已注册的组件值（不能是指针），
+// 每个实体都会得到一份组件数据的拷贝，拷贝语义参考DeepCopier。
+// 与NewEntity后逐个Replace组件不同，所有组件会一次性写入，相关过滤器只计算一次，
+// 每个实体对每个受影响的过滤器只操作一次，适合在加载关卡时大量地创建实体。
+func (w *World) SpawnBatch(count int, components ...any) []Entity {
+	values := make([]componentValue, 0, len(components))
+	for _, component := range components {
+		values = append(values, newComponentValue(component))
+	}
+	values = dedupComponents(values)
+	filters := w.collectFilters(componentTypeIndices(values))
+
+	entities := make([]Entity, 0, count)
+	for range count {
+		entity := w.newEntity()
+		components := make([]componentValue, 0, len(values))
+		for _, c := range values {
+			components = append(components, componentValue{componentType: c.componentType, value: c.componentType.cloneValue(c.value)})
+		}
+		applyComponents(w, entity, w.getEntityData(entity.Id), components, filters)
+		w.Events.EntityCreated.Invoke(entity)
+		entities = append(entities, entity)
 	}
+	return entities
 }
 
 // 根据实体的 ID 获取对应的实体数据。
@@ -80,9 +159,79 @@ func (w *World) freeEntityData(idx int) {
 	entityData := w.entityPool.GetRef(idx)
 	// 代数+1
 	gen := entityData.Gen + 1
+	compIndices := entityData.CompIndices
 	// 调用实体池的 Free 方法释放指定索引的实体数据，该方法会重置数据
 	w.entityPool.Free(idx)
 	entityData.Gen = gen
+	// 与Gen一样保留CompIndices，回收的EntityData被重新分配时Gen不为0，newEntity不会再创建CompIndices
+	clear(compIndices)
+	entityData.CompIndices = compIndices
+}
+
+// DeferredDestroyMode 延迟销毁的entity从过滤器中移除的时机
+type DeferredDestroyMode int
+
+const (
+	// DeferredHideAtFlush entity在FlushDestroyed时才从过滤器中移除，这是默认的模式。
+	// 在此之前其他系统仍然可以在过滤器中看到该entity（IsAlive返回 false），
+	// 过滤器不会被改变，所以可以在遍历过滤器的回调中安全地延迟销毁entity。
+	DeferredHideAtFlush DeferredDestroyMode = iota
+	// DeferredHideImmediately 调用QueueDestroy时立即将entity从所有过滤器中移除。
+	// 注意，这会改变entity所在的过滤器，不能在遍历这些过滤器的回调中使用，
+	// 否则遍历会越界或者漏掉entity。
+	DeferredHideImmediately
+)
+
+// SetDeferredDestroyMode 设置延迟销毁的entity从过滤器中移除的时机，参考DeferredDestroyMode
+func (w *World) SetDeferredDestroyMode(mode DeferredDestroyMode) {
+	w.deferredDestroyMode = mode
+}
+
+// QueueDestroy 延迟销毁entity。
+// entity会立即被标记为正在销毁（IsAlive返回 false），并根据DeferredDestroyMode决定是否立即从过滤器中移除；
+// 真正的销毁（触发销毁事件、删除组件、回收EntityData）在FlushDestroyed时进行。
+// 在此之前entity的组件数据仍然可以读取。
+func (w *World) QueueDestroy(entity Entity) {
+	entityData := w.getEntityData(entity.Id)
+	if !entityData.isCurrentEntityData(entity) {
+		return
+	}
+	if entityData.IsDestroying {
+		return
+	}
+	entityData.IsDestroying = true
+	w.destroyQueue = append(w.destroyQueue, entity)
+	if w.deferredDestroyMode == DeferredHideImmediately {
+		w.hideFromFilters(entity, entityData)
+	}
+}
+
+// FlushDestroyed 销毁所有延迟销毁的entity，是延迟销毁的同步点，通常在每帧（tick）结束时调用。
+// 销毁之前会先调用SyncGroupKeys，触发本帧通过写入路径修改组件所引起的索引事件（比如PredicateFilter的OnAdd/OnRemove），
+// 这些事件的回调中延迟销毁的entity，以及销毁过程中新加入队列的entity，也会在本次调用中一并销毁。
+func (w *World) FlushDestroyed() {
+	w.SyncGroupKeys()
+	for len(w.destroyQueue) > 0 {
+		queue := w.destroyQueue
+		w.destroyQueue = nil
+		for _, entity := range queue {
+			entityData := w.getEntityData(entity.Id)
+			if !entityData.isCurrentEntityData(entity) {
+				continue
+			}
+			doDestroyEntity(w, entity, entityData)
+		}
+	}
+}
+
+// hideFromFilters 将entity从所有包含它的过滤器中移除，
+// entity正在销毁，之后也不会再被加入任何过滤器。
+func (w *World) hideFromFilters(entity Entity, entityData *EntityData) {
+	for typeIndex := range entityData.CompIndices {
+		for _, filter := range w.filterByIncludedComps[typeIndex] {
+			filter.removeEntity(entity)
+		}
+	}
 }
 
 //func (w *World) isCurrentEntityData(entity Entity) bool {
@@ -103,15 +252,25 @@ func getComponentPool[T any](w *World) ComponentPooler {
 		if !ok {
 			panic(fmt.Sprintf("component type %s is not registered", t.Name()))
 		}
-		pool = &ComponentPool[T]{
-			pool: dataPool.NewPool[T](segmentSize),
-		}
+		pool = newComponentPool[T]()
 		w.componentPools[t] = pool
 		w.compTypeIndexPools[componentType.TypeIndex] = pool
 	}
 	return pool
 }
 
+// 根据组件类型数据获取组件池，如果组件池不存在，则会创建一个新的组件池并进行注册。
+// 与getComponentPool不同，它不需要知道组件的具体类型。
+func (w *World) getOrCreateComponentPool(componentType *ComponentType) ComponentPooler {
+	pool, ok := w.compTypeIndexPools[componentType.TypeIndex]
+	if !ok {
+		pool = componentType.newPool()
+		w.componentPools[componentType.Type] = pool
+		w.compTypeIndexPools[componentType.TypeIndex] = pool
+	}
+	return pool
+}
+
 // 根据组件类型索引获取组件池
 func (w *World) getComponentPoolByTypeIndex(typeIndex int) ComponentPooler {
 	return w.compTypeIndexPools[typeIndex]
@@ -182,6 +341,41 @@ func (w *World) updateFiltersBeforeRemove(typeIndex int, entity Entity, entityDa
 	}
 }
 
+// collectFilters 汇总所有包含或排斥typeIndices中任意组件的过滤器，每个过滤器只出现一次。
+func (w *World) collectFilters(typeIndices []int) []IFilter {
+	result := make([]IFilter, 0)
+	seen := make(map[IFilter]struct{})
+	collect := func(filters []IFilter) {
+		for _, filter := range filters {
+			if _, ok := seen[filter]; ok {
+				continue
+			}
+			seen[filter] = struct{}{}
+			result = append(result, filter)
+		}
+	}
+	for _, typeIndex := range typeIndices {
+		collect(w.filterByIncludedComps[typeIndex])
+		collect(w.filterByExcludedComps[typeIndex])
+	}
+	return result
+}
+
+// updateFiltersAfterBatchAdd 用于在entity一次性添加多个组件后更新过滤器。
+// 与updateFiltersAfterAdd不同，每个相关的过滤器只会根据entity的最终组件状态判断一次：
+// 满足过滤条件则加入过滤器，否则从过滤器中移除，
+// 避免了entity在中间状态下反复进出过滤器（特别是排斥过滤器）。
+// 参数 filters 是由collectFilters汇总的相关过滤器。
+func (w *World) updateFiltersAfterBatchAdd(filters []IFilter, entity Entity, entityData *EntityData) {
+	for _, filter := range filters {
+		if filter.isCompatibleAfterAddIncluded(entityData) {
+			filter.addEntity(entity)
+		} else {
+			filter.removeEntity(entity)
+		}
+	}
+}
+
 // RegisterFilter 向指定的world注册一个filter
 // 目前过滤器在使用之前都要先Register，并且要在world.NewEntity()之前注册
 // 如果在world.NewEntity()之后注册，会导致Filter中的entity数量不准确
@@ -211,6 +405,16 @@ func RegisterFilter[T IFilter](w *World, filter T) T {
 	return filter
 }
 
+// 判断filter是否已经通过RegisterFilter注册到world中
+func (w *World) isFilterRegistered(filter IFilter) bool {
+	for _, registered := range w.filters {
+		if registered == filter {
+			return true
+		}
+	}
+	return false
+}
+
 // RegisterGroupFilter 向指定的world注册一个groupFilter。
 // 目前过滤器在使用之前都要先Register，并且要在world.NewEntity()之前注册。
 // 如果在world.NewEntity()之后注册，会导致Filter中的entity数量不准确。
@@ -279,12 +483,39 @@ func GetGroupFilter[T IGroupFilter](w *World) T {
 	return filter.(T)
 }
 
+// RegisterGroupFilterNamed 以指定的名称向world注册一个groupFilter，
+// 用于同一类型的groupFilter需要注册多个的情况，比如多个Key类型相同的GroupFilterFunc。
+// 注册的时机要求与RegisterGroupFilter相同。
+func RegisterGroupFilterNamed[T IGroupFilter](w *World, name string, filter T) T {
+	if _, ok := w.groupFilters[name]; ok {
+		panic("repeat register filter")
+	}
+	w.groupFilters[name] = filter
+	return filter
+}
+
+// GetGroupFilterNamed 获取通过RegisterGroupFilterNamed注册的groupFilter，
+// 未注册或者类型不匹配时触发 panic。
+func GetGroupFilterNamed[T IGroupFilter](w *World, name string) T {
+	filter, ok := w.groupFilters[name]
+	if !ok {
+		// 不允许使用未注册的过滤器
+		panic(fmt.Sprintf("filter:%s not registered", name))
+	}
+	return filter.(T)
+}
+
 // 注册相关的groupKey事件
 func registerGroupKeyEventByType[T any](world *World, entitySet iEntitySet, filter IFilter) *groupKeyEventProxy {
-	componentType := GetComponentType[T]()
+	return registerGroupKeyEventByTypeIndex(world, GetComponentType[T]().TypeIndex, entitySet, filter)
+}
+
+// 注册相关的groupKey事件，与registerGroupKeyEventByType相同，但是使用组件类型索引
+func registerGroupKeyEventByTypeIndex(world *World, typeIndex int, entitySet iEntitySet, filter IFilter) *groupKeyEventProxy {
 	proxy := &groupKeyEventProxy{set: entitySet, filter: filter}
-	world.registerGroupKeyEvent(componentType.TypeIndex, groupKeyAdd, proxy)
-	world.registerGroupKeyEvent(componentType.TypeIndex, groupKeyRemove, proxy)
+	world.registerGroupKeyEvent(typeIndex, groupKeyAdd, proxy)
+	world.registerGroupKeyEvent(typeIndex, groupKeyRemove, proxy)
+	recordGroupKeyType(proxy, typeIndex)
 	return proxy
 }
 
@@ -293,9 +524,21 @@ func registerGroupKeyEventByTypeAndHandler[T any](world *World, handler groupKey
 	componentType := GetComponentType[T]()
 	world.registerGroupKeyEvent(componentType.TypeIndex, groupKeyAdd, handler)
 	world.registerGroupKeyEvent(componentType.TypeIndex, groupKeyRemove, handler)
+	recordGroupKeyType(handler, componentType.TypeIndex)
 	return handler
 }
 
+// 记录索引所依赖的key组件，查询索引时只同步这些组件的写入，参考groupKeySyncer
+func recordGroupKeyType(handler groupKeyEventHandler, typeIndex int) {
+	proxy, ok := handler.(*groupKeyEventProxy)
+	if !ok {
+		return
+	}
+	if dependent, ok := proxy.set.(interface{ addGroupKeyType(typeIndex int) }); ok {
+		dependent.addGroupKeyType(typeIndex)
+	}
+}
+
 func (w *World) registerGroupKeyEvent(typeIndex int, eventEnum groupKeyEventKind, handler groupKeyEventHandler) {
 	w.groupKeyEventReceivers[typeIndex] = append(w.groupKeyEventReceivers[typeIndex], groupKeyEvent{
 		eventKind: eventEnum,
@@ -313,6 +556,78 @@ func (w *World) fireGroupKeyEvent(typeIndex int, eventEnum groupKeyEventKind, en
 	}
 }
 
+// 等待重新加入groupFilter的entity及其被修改的key组件类型索引
+type pendingGroupKey struct {
+	typeIndex int
+	entity    Entity
+}
+
+// 写入路径（GetForWrite、MarkDirty等）调用：typeIndex对应的组件数据即将被修改，
+// 若它是某些groupFilter的key，则先以旧的key将entity从这些groupFilter中移除，
+// 并记录下来，等待SyncGroupKeys时以新的key重新加入。
+func (w *World) beginGroupKeyWrite(typeIndex int, entity Entity) {
+	if _, ok := w.groupKeyEventReceivers[typeIndex]; !ok {
+		return
+	}
+	// 即使已经在等待中，entity也可能在此期间被Replace等重新加入了groupFilter，所以总是先移除
+	w.fireGroupKeyEvent(typeIndex, groupKeyRemove, entity)
+	pending := pendingGroupKey{typeIndex: typeIndex, entity: entity}
+	if _, ok := w.pendingGroupKeySet[pending]; ok {
+		return
+	}
+	w.pendingGroupKeySet[pending] = struct{}{}
+	w.pendingGroupKeys[typeIndex] = append(w.pendingGroupKeys[typeIndex], entity)
+}
+
+// SyncGroupKeys 将通过写入路径（GetForWrite、GetMayForWrite、MarkDirty等）修改了key组件的entity，
+// 按新的key重新加入相关的groupFilter、SpatialIndex、SortedView、PredicateFilter等索引。
+// 查询索引时会自动同步该索引所依赖的key组件，一般不需要手动调用；
+// 每帧结束时FlushDestroyed会调用它，保证索引的监听（比如PredicateFilter的OnAdd/OnRemove）在每帧都会被触发；
+// 如果需要更早地触发这些监听，可以在每帧的其他固定时机（比如每个系统执行之后）手动调用。
+// 注意，它会同步所有的索引，比如UniqueConflictPanic策略的唯一索引可能因此panic。
+func (w *World) SyncGroupKeys() {
+	// 回调中新产生的写入留到下一次同步，按类型索引的顺序同步，保证结果是确定的
+	for _, typeIndex := range slices.Sorted(maps.Keys(w.pendingGroupKeys)) {
+		w.syncGroupKeysOf(typeIndex)
+	}
+}
+
+// syncGroupKeys 只同步typeIndices对应的key组件，查询索引时使用，
+// 避免查询一个索引时触发其他无关索引的更新（比如其他唯一索引的冲突）。
+func (w *World) syncGroupKeys(typeIndices []int) {
+	if len(w.pendingGroupKeys) == 0 {
+		return
+	}
+	for _, typeIndex := range typeIndices {
+		w.syncGroupKeysOf(typeIndex)
+	}
+}
+
+// 同步typeIndex对应的key组件
+func (w *World) syncGroupKeysOf(typeIndex int) {
+	entities, ok := w.pendingGroupKeys[typeIndex]
+	if !ok {
+		return
+	}
+	delete(w.pendingGroupKeys, typeIndex)
+	for _, entity := range entities {
+		delete(w.pendingGroupKeySet, pendingGroupKey{typeIndex: typeIndex, entity: entity})
+	}
+	for _, entity := range entities {
+		// 期间entity可能已经被销毁。
+		// 延迟销毁、尚未FlushDestroyed的entity仍要重新加入索引：DeferredHideAtFlush模式下它仍在filter中，
+		// 直到FlushDestroyed时才从索引中移除；已经从filter中移除的entity会被groupKeyEventProxy忽略
+		if !entity.getEntityData().isCurrentEntityData(entity) {
+			continue
+		}
+		// 期间组件可能已经被删除，entity也就不在相关的filter中了
+		if _, ok := entity.getEntityData().CompIndices[typeIndex]; !ok {
+			continue
+		}
+		w.fireGroupKeyEvent(typeIndex, groupKeyAdd, entity)
+	}
+}
+
 // EntityData 结构体表示一个entity的基本数据，它在World中池化存储，
 // World通过entity的id来访问EntityData，entity的id是World中EntityData池中的索引。
 type EntityData struct {
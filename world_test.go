@@ -0,0 +1,409 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type worldTestHp struct {
+	Val int
+}
+
+type worldTestTag struct{}
+
+type worldTestItems struct {
+	Ids []int
+}
+
+func init() {
+	RegisterComponentType[worldTestHp](16)
+	RegisterComponentType[worldTestTag](16)
+	RegisterComponentType[worldTestItems](16)
+}
+
+func newWorldTestWorld(count int) (*World, *Filter1[worldTestHp], []Entity) {
+	world := NewWorld()
+	filter := RegisterFilter(world, NewFilter1[worldTestHp](world))
+	entities := make([]Entity, count)
+	for i := range entities {
+		entities[i] = world.NewEntity()
+		Replace(entities[i], worldTestHp{Val: i})
+	}
+	return world, filter, entities
+}
+
+func TestDeferredDestroyInsideForeach(t *testing.T) {
+	tests := []struct {
+		name string
+		// nil表示使用默认模式
+		mode *DeferredDestroyMode
+		// 遍历中每个entity都延迟销毁时，期望遍历到的entity数量
+		visited int
+		// FlushDestroyed之前过滤器中的entity数量
+		lenBeforeFlush int
+	}{
+		{name: "default", visited: 5, lenBeforeFlush: 5},
+		{name: "hide at flush", mode: ptrOf(DeferredHideAtFlush), visited: 5, lenBeforeFlush: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, filter, entities := newWorldTestWorld(5)
+			if tt.mode != nil {
+				world.SetDeferredDestroyMode(*tt.mode)
+			}
+			visited := 0
+			filter.Foreach(func(entity Entity, hp worldTestHp) {
+				visited++
+				entity.DestroyDeferred()
+				if entity.IsAlive() {
+					t.Errorf("entity %+v is alive after DestroyDeferred", entity)
+				}
+			})
+			if visited != tt.visited {
+				t.Errorf("visited = %d, want %d", visited, tt.visited)
+			}
+			if got := filter.Len(); got != tt.lenBeforeFlush {
+				t.Errorf("Len before flush = %d, want %d", got, tt.lenBeforeFlush)
+			}
+			world.FlushDestroyed()
+			if got := filter.Len(); got != 0 {
+				t.Errorf("Len after flush = %d, want 0", got)
+			}
+			for _, entity := range entities {
+				if entity.IsAlive() {
+					t.Errorf("entity %+v is alive after flush", entity)
+				}
+			}
+		})
+	}
+}
+
+func TestDeferredHideImmediately(t *testing.T) {
+	world, filter, entities := newWorldTestWorld(5)
+	world.SetDeferredDestroyMode(DeferredHideImmediately)
+	entities[1].DestroyDeferred()
+	entities[3].DestroyDeferred()
+	if got := filter.Len(); got != 3 {
+		t.Errorf("Len before flush = %d, want 3", got)
+	}
+	// 组件数据在FlushDestroyed之前仍然可以读取
+	if got := Get[worldTestHp](entities[1]).Val; got != 1 {
+		t.Errorf("hp = %d, want 1", got)
+	}
+	world.FlushDestroyed()
+	if got := filter.Len(); got != 3 {
+		t.Errorf("Len after flush = %d, want 3", got)
+	}
+}
+
+func TestDeferredHideImmediatelyReplaceKey(t *testing.T) {
+	tests := []struct {
+		name string
+		// 创建依赖worldTestHp的索引，返回判断entity是否在索引中的函数
+		index func(world *World, filter *Filter1[worldTestHp]) func(entity Entity) bool
+	}{
+		{name: "GroupFilter", index: func(world *World, filter *Filter1[worldTestHp]) func(entity Entity) bool {
+			gf := NewGroupFilter[worldTestHp](world)
+			return func(entity Entity) bool {
+				found, ok := gf.FindOne(worldTestHp{Val: 100})
+				return ok && found == entity
+			}
+		}},
+		{name: "SortedView", index: func(world *World, filter *Filter1[worldTestHp]) func(entity Entity) bool {
+			view := SortBy(filter, func(a, b *worldTestHp) int { return a.Val - b.Val })
+			return func(entity Entity) bool {
+				return slices.Contains(view.Entities(), entity)
+			}
+		}},
+		{name: "PredicateFilter", index: func(world *World, filter *Filter1[worldTestHp]) func(entity Entity) bool {
+			pf := NewPredicateFilter1(world, func(hp *worldTestHp) bool { return hp.Val >= 100 })
+			return pf.Contains
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			world.SetDeferredDestroyMode(DeferredHideImmediately)
+			filter := RegisterFilter(world, NewFilter1[worldTestHp](world))
+			contains := tt.index(world, filter)
+			entity := world.NewEntity()
+			Replace(entity, worldTestHp{Val: 1})
+			entity.DestroyDeferred()
+			// 已经从过滤器中移除的entity修改key，不能重新加入索引
+			Replace(entity, worldTestHp{Val: 100})
+			if contains(entity) {
+				t.Errorf("entity is indexed after Replace before flush")
+			}
+			world.FlushDestroyed()
+			if contains(entity) {
+				t.Errorf("destroyed entity is still indexed after flush")
+			}
+		})
+	}
+}
+
+func TestDeferredHideAtFlushWriteKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(entity Entity)
+	}{
+		{name: "GetForWrite", write: func(entity Entity) { GetForWrite[worldTestHp](entity).Val = 100 }},
+		{name: "MarkDirty", write: func(entity Entity) {
+			Get[worldTestHp](entity).Val = 100
+			MarkDirty[worldTestHp](entity)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			filter := RegisterFilter(world, NewFilter1[worldTestHp](world))
+			gf := NewGroupFilter[worldTestHp](world)
+			entity := world.NewEntity()
+			Replace(entity, worldTestHp{Val: 1})
+			entity.DestroyDeferred()
+			// 延迟销毁的entity在FlushDestroyed之前仍然可见，写入key后按新的key重新加入索引
+			tt.write(entity)
+			if got := filter.Len(); got != 1 {
+				t.Errorf("filter Len before flush = %d, want 1", got)
+			}
+			if found, ok := gf.FindOne(worldTestHp{Val: 100}); !ok || found != entity {
+				t.Errorf("FindOne(new key) before flush = %v, %v, want %v, true", found, ok, entity)
+			}
+			world.FlushDestroyed()
+			if _, ok := gf.FindOne(worldTestHp{Val: 100}); ok {
+				t.Errorf("destroyed entity is still indexed after flush")
+			}
+		})
+	}
+}
+
+func TestDestroyIgnoresComponentsAddedByCallbacks(t *testing.T) {
+	tests := []struct {
+		name    string
+		destroy func(world *World, entity Entity)
+	}{
+		{name: "Destroy", destroy: func(world *World, entity Entity) { entity.Destroy() }},
+		{name: "DestroyDeferred", destroy: func(world *World, entity Entity) {
+			entity.DestroyDeferred()
+			world.FlushDestroyed()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, filter, entities := newWorldTestWorld(1)
+			entity := entities[0]
+			// 删除组件时再添加组件，销毁不能因此陷入死循环
+			sub := GetComponentType[worldTestHp]().Events.AfterDelete.AddCallback(func(e Entity) {
+				Replace(e, worldTestHp{Val: 100})
+				Replace(e, worldTestTag{})
+			})
+			defer sub.Unsubscribe()
+			tt.destroy(world, entity)
+			if entity.IsAlive() {
+				t.Errorf("entity is alive after destroy")
+			}
+			if got := filter.Len(); got != 0 {
+				t.Errorf("Len = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestNewEntityRecyclesId(t *testing.T) {
+	tests := []struct {
+		name    string
+		destroy func(world *World, entity Entity)
+	}{
+		{name: "Destroy", destroy: func(world *World, entity Entity) { entity.Destroy() }},
+		{name: "DestroyDeferred", destroy: func(world *World, entity Entity) {
+			entity.DestroyDeferred()
+			world.FlushDestroyed()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, filter, entities := newWorldTestWorld(1)
+			old := entities[0]
+			tt.destroy(world, old)
+			// 回收的id被重新分配，代数增加，可以正常地添加组件
+			entity := world.NewEntity()
+			if entity.Id != old.Id || entity.Gen != old.Gen+1 {
+				t.Fatalf("NewEntity = %+v, want id %d gen %d", entity, old.Id, old.Gen+1)
+			}
+			Replace(entity, worldTestHp{Val: 10})
+			Replace(entity, worldTestTag{})
+			if got := Get[worldTestHp](entity).Val; got != 10 {
+				t.Errorf("hp = %d, want 10", got)
+			}
+			if old.IsAlive() {
+				t.Errorf("stale entity is alive after its id is recycled")
+			}
+			if got := filter.Len(); got != 1 {
+				t.Errorf("filter Len = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestDestroyOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		destroy func(world *World, entity Entity)
+	}{
+		{name: "Destroy", destroy: func(world *World, entity Entity) { entity.Destroy() }},
+		{name: "DestroyDeferred", destroy: func(world *World, entity Entity) {
+			entity.DestroyDeferred()
+			world.FlushDestroyed()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			parent, entity, child, source := world.NewEntity(), world.NewEntity(), world.NewEntity(), world.NewEntity()
+			nameOf := map[Entity]string{parent: "parent", entity: "entity", child: "child", source: "source"}
+			Replace(entity, worldTestHp{Val: 7})
+			SetParent(entity, parent)
+			SetParent(child, entity)
+			AddRelation[relationTestOwnedBy](source, entity)
+
+			var log []string
+			world.OnEntityDestroying(func(e Entity) {
+				log = append(log, "destroying "+nameOf[e])
+				if e == entity {
+					// 此时组件、父子关系、关系都还可以读取
+					if got := Get[worldTestHp](e).Val; got != 7 {
+						t.Errorf("hp in EntityDestroying = %d, want 7", got)
+					}
+					if p, ok := GetParent(e); !ok || p != parent {
+						t.Errorf("parent in EntityDestroying = %v, %v, want parent", p, ok)
+					}
+					if !HasRelation[relationTestOwnedBy](source, e) {
+						t.Errorf("relation is removed before EntityDestroying")
+					}
+				}
+			})
+			world.OnEntityDestroyed(func(e Entity) {
+				log = append(log, "destroyed "+nameOf[e])
+			})
+			OnRemove(world, func(e Entity, old *worldTestHp) {
+				log = append(log, "remove hp "+nameOf[e])
+				if old.Val != 7 {
+					t.Errorf("hp in OnRemove = %d, want 7", old.Val)
+				}
+			})
+			OnRemove(world, func(e Entity, old *ParentComponent) {
+				log = append(log, "remove parent "+nameOf[e])
+			})
+			OnRemove(world, func(e Entity, old *ChildrenComponent) {
+				log = append(log, "remove children "+nameOf[e])
+			})
+
+			tt.destroy(world, entity)
+
+			// 每条记录所属的阶段（参考destroyEntity的注释），记录必须按阶段的顺序出现，同一阶段内的顺序不做要求
+			phases := map[string]int{
+				// EntityDestroying
+				"destroying entity": 1,
+				// 断开父子关系：先从父entity的子列表中移除，再使子entity成为根entity
+				"remove children parent": 2,
+				"remove parent child":    3,
+				// 清理关系，连带销毁源entity
+				"destroying source": 4,
+				"destroyed source":  4,
+				// 逐个删除组件，触发OnRemove
+				"remove hp entity":       5,
+				"remove parent entity":   5,
+				"remove children entity": 5,
+				// EntityDestroyed
+				"destroyed entity": 6,
+			}
+			if len(log) != len(phases) {
+				t.Fatalf("log = %v, want %d entries", log, len(phases))
+			}
+			last := 0
+			for _, entry := range log {
+				phase, ok := phases[entry]
+				if !ok || phase < last {
+					t.Fatalf("log = %v, unexpected %q", log, entry)
+				}
+				last = phase
+			}
+		})
+	}
+}
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
+func TestSpawnBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []any
+		// 期望每个过滤器的OnEntityAdded次数
+		wantHp, wantUntagged int
+	}{
+		{name: "include", components: []any{worldTestHp{Val: 7}, worldTestItems{Ids: []int{1}}}, wantHp: 3, wantUntagged: 3},
+		{name: "exclude", components: []any{worldTestHp{Val: 7}, worldTestItems{Ids: []int{1}}, worldTestTag{}}, wantHp: 3},
+		{name: "duplicate component", components: []any{worldTestHp{Val: 1}, worldTestItems{Ids: []int{1}}, worldTestHp{Val: 7}}, wantHp: 3, wantUntagged: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			hpFilter := RegisterFilter(world, NewFilter1[worldTestHp](world))
+			untagged := RegisterFilter(world, NewFilter1Exclude[worldTestHp, worldTestTag](world))
+			hpEvents := recordFilterEvents(hpFilter)
+			untaggedEvents := recordFilterEvents(untagged)
+
+			entities := world.SpawnBatch(3, tt.components...)
+			if len(entities) != 3 {
+				t.Fatalf("len(entities) = %d, want 3", len(entities))
+			}
+			// 所有组件一次性加入，每个entity在每个过滤器中只加入一次，不会先加入再移除
+			if len(hpEvents.added) != tt.wantHp || len(hpEvents.removed) != 0 {
+				t.Errorf("filter added %d, removed %d, want %d, 0", len(hpEvents.added), len(hpEvents.removed), tt.wantHp)
+			}
+			if len(untaggedEvents.added) != tt.wantUntagged || len(untaggedEvents.removed) != 0 {
+				t.Errorf("exclude filter added %d, removed %d, want %d, 0", len(untaggedEvents.added), len(untaggedEvents.removed), tt.wantUntagged)
+			}
+			for _, entity := range entities {
+				if got := Get[worldTestHp](entity).Val; got != 7 {
+					t.Errorf("hp = %d, want 7", got)
+				}
+			}
+			// 每个entity拥有独立的组件数据
+			Get[worldTestItems](entities[0]).Ids[0] = 2
+			for _, entity := range entities[1:] {
+				if got := Get[worldTestItems](entity).Ids; !slices.Equal(got, []int{1}) {
+					t.Errorf("items = %v, want [1]", got)
+				}
+			}
+		})
+	}
+}
+
+func TestAddComponents(t *testing.T) {
+	world := NewWorld()
+	hpFilter := RegisterFilter(world, NewFilter1[worldTestHp](world))
+	untagged := RegisterFilter(world, NewFilter1Exclude[worldTestHp, worldTestTag](world))
+	hpEvents := recordFilterEvents(hpFilter)
+	untaggedEvents := recordFilterEvents(untagged)
+	entity := world.NewEntity()
+
+	AddComponents(entity, worldTestHp{Val: 1}, worldTestTag{})
+	if len(hpEvents.added) != 1 || len(untaggedEvents.added) != 0 || len(untaggedEvents.removed) != 0 {
+		t.Errorf("after AddComponents: filter added %d, exclude filter added %d, removed %d, want 1, 0, 0",
+			len(hpEvents.added), len(untaggedEvents.added), len(untaggedEvents.removed))
+	}
+	// 替换已有的组件，不会重复加入过滤器
+	AddComponents(entity, worldTestHp{Val: 2}, worldTestItems{})
+	if got := Get[worldTestHp](entity).Val; got != 2 {
+		t.Errorf("hp = %d, want 2", got)
+	}
+	if !Has[worldTestItems](entity) {
+		t.Errorf("entity does not have worldTestItems")
+	}
+	if len(hpEvents.added) != 1 || len(hpEvents.removed) != 0 {
+		t.Errorf("filter added %d, removed %d, want 1, 0", len(hpEvents.added), len(hpEvents.removed))
+	}
+}